@@ -2,21 +2,41 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/dmlittle/scenery/pkg/parser"
 	"github.com/dmlittle/scenery/pkg/printer"
+	"github.com/dmlittle/scenery/pkg/viewer"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	yaml "gopkg.in/yaml.v2"
 )
 
 var (
 	sceneryVersion string
 
-	noColor bool
+	noColor      bool
+	reveal       bool
+	hideComputed bool
+	onlyChanged  bool
+	compact      bool
+	width        int
+	noTruncate   bool
+	inlineDiffs  bool
+	noPager      bool
+	diffContext  int
+	themeSpec    string
+	accessible   bool
+	hyperlinks   string
+	docsLinks    string
+	strict       bool
+	jobs         int
 )
 
 // Execute is the entrypoint of the CLI.
@@ -24,14 +44,90 @@ func Execute(version string) {
 	sceneryVersion = version
 
 	cmd := &cobra.Command{
-		Use:     "scenery",
-		Short:   "CLI for prettifying Terraform plan outputs",
-		Example: "  terraform plan | scenery",
+		Use:   "scenery [plan...]",
+		Short: "CLI for prettifying Terraform plan outputs",
+		Long: "Prettify a Terraform plan read from stdin or from a file. Several files,\n" +
+			"directories or glob patterns may be given to print many plans at once,\n" +
+			"followed by the total of their changes.",
+		Example: "  terraform plan | scenery\n  scenery plans/\n  scenery 'envs/*/plan.txt'",
 		Version: sceneryVersion,
+		Args:    cobra.ArbitraryArgs,
 		Run:     runScenery,
+
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := loadConfig(cmd); err != nil {
+				os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+				os.Exit(1)
+			}
+		},
 	}
 
 	cmd.PersistentFlags().BoolVarP(&noColor, "no-color", "n", false, "Print output without color")
+	cmd.PersistentFlags().BoolVar(&reveal, "reveal", false, "Print detected secrets instead of redacting them")
+	cmd.PersistentFlags().BoolVar(&hideComputed, "hide-computed", false, "Collapse attributes only known after apply into a count")
+	cmd.PersistentFlags().BoolVar(&onlyChanged, "only-changed", false, "Collapse attributes without changes into a count")
+	cmd.PersistentFlags().BoolVar(&compact, "compact", false, "Shorthand for --hide-computed --only-changed without blank lines between resources")
+	cmd.PersistentFlags().IntVar(&width, "width", 0, "Wrap long values to the given number of columns (defaults to the terminal width)")
+	cmd.PersistentFlags().BoolVar(&noTruncate, "no-truncate", false, "Print long values in full instead of truncating them")
+	cmd.PersistentFlags().BoolVar(&inlineDiffs, "inline-diffs", false, "Print changed values as \"before\" => \"after\" instead of unified diffs")
+	cmd.PersistentFlags().BoolVar(&noPager, "no-pager", false, "Print output directly instead of through $SCENERY_PAGER or $PAGER")
+	cmd.PersistentFlags().BoolVar(&accessible, "accessible", false, "Spell out every change in words and label before/after values, e.g. for screen readers")
+	cmd.PersistentFlags().BoolVar(&strict, "strict", false, "Exit with a non-zero status when the plan summary does not match the resources shown")
+	cmd.PersistentFlags().StringVar(&hyperlinks, "hyperlinks", "auto", "Link resource types to their documentation: auto (when printing to a terminal), always or never")
+	cmd.PersistentFlags().StringVar(&docsLinks, "docs-links", "", "YAML file mapping providers to documentation URL templates")
+	cmd.PersistentFlags().StringVar(&themeSpec, "theme", "default", fmt.Sprintf("Color theme (%s), optionally followed by style overrides, e.g. \"deuteranopia,create=#56b4e9\"", strings.Join(printer.ThemeNames(), ", ")))
+	cmd.PersistentFlags().IntVarP(&jobs, "jobs", "j", 0, "Number of plans parsed concurrently when printing several (defaults to the number of CPUs)")
+	cmd.PersistentFlags().IntVar(&diffContext, "diff-context", 5, "Number of unchanged lines shown around changes in diffs")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "view [plan]",
+		Short:   "Browse a plan interactively",
+		Long:    "Browse a plan in a full-screen viewer. Resources can be expanded and\ncollapsed, searched and jumped between by action. Press ? for the list of keys.",
+		Example: "  terraform plan | scenery view",
+		Args:    cobra.MaximumNArgs(1),
+		Run:     runView,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "apply-log [log]",
+		Short: "Prettify the output of terraform apply",
+		Long: "Print the change applied to each resource along with how long it took and\n" +
+			"its errors, followed by the slowest resources and the summary of the apply.",
+		Example: "  terraform apply | tee apply.log\n  scenery apply-log apply.log",
+		Args:    cobra.MaximumNArgs(1),
+		Run:     runApplyLog,
+	})
+
+	runCmd := &cobra.Command{
+		Use:   "run -- command [args...]",
+		Short: "Run a command and prettify the plan it outputs",
+		Long: "Run a command (usually terraform plan) and prettify the plan it prints. The\n" +
+			"command's stderr is printed as-is and scenery exits with the command's exit\n" +
+			"code, so that e.g. -detailed-exitcode keeps working. The command's output is\n" +
+			"only printed once it exited, so it is run with TF_INPUT=0 to never prompt.",
+		Example: "  scenery run -- terraform plan -detailed-exitcode",
+		Args:    cobra.MinimumNArgs(1),
+		Run:     runCommand,
+	}
+	// Flags following the command belong to it
+	runCmd.Flags().SetInterspersed(false)
+	cmd.AddCommand(runCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+		Long: "Settings default to the values in $XDG_CONFIG_HOME/scenery/config.yml, overridden\n" +
+			"by the values in the .scenery.yml of the current repository, SCENERY_* environment\n" +
+			"variables (e.g. SCENERY_HIDE_COMPUTED=true) and flags, in that order. Settings\n" +
+			"are named after their flags, e.g. `hide-computed: true`.",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the effective settings and where they come from",
+		Args:  cobra.NoArgs,
+		Run:   runConfigShow,
+	})
+	cmd.AddCommand(configCmd)
 
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
@@ -39,58 +135,378 @@ func Execute(version string) {
 }
 
 func runScenery(cmd *cobra.Command, args []string) {
-	var input string
-	var foundInput bool
+	setOptions()
 
-	if noColor || cmd.Flags().Changed("no-color") {
-		color.NoColor = noColor
+	if len(args) > 1 || (len(args) == 1 && isMultiplePlans(args[0])) {
+		runPlans(args)
+		return
 	}
 
-	stat, _ := os.Stdin.Stat() // nolint: gosec
+	input, ok := openInput(cmd, args)
+	if !ok {
+		return
+	}
+	defer input.Close() // nolint: errcheck
 
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		var lines []string
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+	r, kind, err := detectInput(input)
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("Failed to read input: %s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	switch kind {
+	case modulesInput:
+		runModules(readAll(r))
+		return
+	case stateInput:
+		runState(readAll(r))
+		return
+	}
+
+	out := newOutput()
+	stream := printer.NewStream(out)
+	chunks := parser.NewChunkReader(r)
+
+	// Plans are printed a resource at a time as they are read so that huge
+	// plans do not need to be held in memory.
+	failed := false
+	printed := false
+	for {
+		chunk, err := chunks.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			os.Stderr.WriteString(color.RedString("Failed to read input: %s\n", err)) // nolint: gosec
+			failed = true
+			break
 		}
 
-		input = strings.Join(lines, "\n")
-		foundInput = true
+		plan := parseChunk(chunk, chunks.First(), chunks.Last())
+		if plan == nil && !printed {
+			// Nothing was printed yet so the whole input is passed through
+			os.Stderr.WriteString(color.RedString("Failed to parse plan. Returning original input.\n")) // nolint: gosec
+			fmt.Fprint(out, chunk)
+			for {
+				chunk, err := chunks.Next()
+				if err != nil {
+					break
+				}
+				fmt.Fprint(out, chunk)
+			}
+			out.Close()
+			os.Exit(1)
+		}
+
+		if plan == nil {
+			stream.PrintUnparsed(chunk)
+			failed = true
+			continue
+		}
+
+		stream.Print(plan)
+		printed = true
+		out.Flush()
+	}
+
+	stream.Close()
+	out.Close()
+
+	if failed || (strict && stream.CheckSummary() != nil) {
+		os.Exit(1)
+	}
+}
 
-	} else if len(args) == 1 {
-		fileContents, err := ioutil.ReadFile(args[0])
+func runView(cmd *cobra.Command, args []string) {
+	// The viewer draws on the terminal even when stdout is redirected so
+	// colors are only disabled when explicitly asked for.
+	color.NoColor = noColor
+
+	setOptions()
+
+	plan, ok := readPlan(cmd, args)
+	if !ok {
+		return
+	}
+
+	if err := viewer.Run(plan); err != nil {
+		os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+}
+
+func runApplyLog(cmd *cobra.Command, args []string) {
+	setOptions()
+
+	r, ok := openInput(cmd, args)
+	if !ok {
+		return
+	}
+	defer r.Close() // nolint: errcheck
+
+	input := strings.TrimRight(readAll(r), "\n")
+
+	apply, err := parser.ParseApply(input)
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("Failed to parse apply output. Returning original input.\n")) // nolint: gosec
+		fmt.Println(input)
+		os.Exit(1)
+	}
+
+	out := newOutput()
+	printer.FprintApply(out, apply)
+	out.Close()
+
+	// Let scripts tell failed applies apart as the exit code of Terraform is
+	// lost when piping.
+	for _, r := range apply.Resources {
+		if len(r.Errors) > 0 {
+			os.Exit(1)
+		}
+	}
+	if len(apply.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runState prints the resources of the output of `terraform state show` or
+// `terraform show`.
+func runState(input string) {
+	state, err := parser.ParseState(input)
+	if err != nil {
+		passthrough(strings.TrimRight(input, "\n"), 1)
+		return
+	}
+
+	out := newOutput()
+	printer.FprintState(out, state)
+	out.Close()
+}
+
+func setOptions() {
+	if noColor || isSet("no-color") {
+		color.NoColor = noColor
+	}
+
+	if !isSet("width") {
+		width = terminalWidth()
+	}
+
+	if diffContext < 0 {
+		os.Stderr.WriteString(color.RedString("invalid value %d for --diff-context, expected 0 or more\n", diffContext)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	theme, err := printer.ParseTheme(themeSpec)
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	links, templates, err := hyperlinkOptions()
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	if key := os.Getenv("SCENERY_REDACTION_KEY"); key != "" {
+		printer.SetRedactionKey([]byte(key))
+	}
+
+	printer.SetOptions(printer.Options{
+		Reveal:           reveal,
+		HideComputed:     hideComputed,
+		OnlyChanged:      onlyChanged,
+		Compact:          compact,
+		Width:            width,
+		NoTruncate:       noTruncate,
+		InlineDiffs:      inlineDiffs,
+		DiffContext:      &diffContext,
+		Theme:            theme,
+		Accessible:       accessible,
+		Hyperlinks:       links,
+		DocsURLTemplates: templates,
+	})
+}
+
+// openInput opens the file given as argument, or else stdin if a plan is piped
+// in. It prints the usage and returns false if there is nothing to read.
+func openInput(cmd *cobra.Command, args []string) (io.ReadCloser, bool) {
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
 		if err != nil {
 			cmd.Usage() // nolint: gosec
-			return
+			return nil, false
 		}
 
-		input = string(fileContents)
-		foundInput = true
+		return f, true
 	}
 
-	if foundInput {
-		plan, err := parser.Parse(input)
-		if err != nil {
-			if err == parser.ErrParseFailure {
-				os.Stderr.WriteString(color.RedString("Failed to parse plan. Returning original input.\n")) // nolint: gosec
-				fmt.Println(input)
-				os.Exit(1)
-				return
+	stat, _ := os.Stdin.Stat() // nolint: gosec
+
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		return os.Stdin, true
+	}
+
+	cmd.Usage() // nolint: gosec
+	return nil, false
+}
+
+// inputKind is the kind of output scenery was given to prettify.
+type inputKind int
+
+const (
+	planInput    inputKind = iota
+	modulesInput           // interleaved plans of a Terragrunt `run-all` command
+	stateInput             // output of `terraform state show` or `terraform show`
+)
+
+// moduleLines is the number of lines of output which must all be prefixed
+// with a module for the input to be taken as the output of Terragrunt, so that
+// plans merely starting with a line such as `[INFO] starting` are not.
+const moduleLines = 3
+
+// detectInput reads the input up to its first lines of output (skipping blank
+// lines and Terragrunt's own logs) to find out what kind of output it is. The
+// returned reader reads the whole input, including the lines read to find out.
+func detectInput(input io.Reader) (io.Reader, inputKind, error) {
+	r := bufio.NewReader(input)
+	var head bytes.Buffer
+	prefixed := 0
+
+	for {
+		line, err := r.ReadString('\n')
+		head.WriteString(line)
+
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "[terragrunt]") {
+			if _, ok := parser.ModuleOf(strings.TrimSuffix(line, "\n")); ok {
+				prefixed++
+				if prefixed == moduleLines {
+					return io.MultiReader(&head, r), modulesInput, nil
+				}
+			} else {
+				if prefixed == 0 && parser.IsStateHeader(line) {
+					return io.MultiReader(&head, r), stateInput, nil
+				}
+
+				return io.MultiReader(&head, r), planInput, nil
 			}
 		}
 
-		// plan will be nil if the parser panicked (potentially due to unrecognized
-		// character or sequences) so we return the original input.
-		if plan == nil {
-			os.Stderr.WriteString(color.RedString("Failed to parse plan. Returning original input.\n")) // nolint: gosec
-			fmt.Println(input)
-			os.Exit(1)
-			return
+		if err == io.EOF {
+			if prefixed > 1 {
+				return io.MultiReader(&head, r), modulesInput, nil
+			}
+			return io.MultiReader(&head, r), planInput, nil
+		}
+		if err != nil {
+			return nil, planInput, err
 		}
+	}
+}
+
+// readPlan reads the whole plan from stdin or from the file given as argument
+// and parses it. If the plan cannot be parsed the original input is printed
+// and the process exits.
+func readPlan(cmd *cobra.Command, args []string) (*parser.Plan, bool) {
+	r, ok := openInput(cmd, args)
+	if !ok {
+		return nil, false
+	}
+	defer r.Close() // nolint: errcheck
+
+	input := strings.TrimRight(readAll(r), "\n")
+
+	plan := parsePlan(input)
+	if plan == nil {
+		passthrough(input, 1)
+		return nil, false
+	}
+
+	return plan, true
+}
+
+// readAll reads the whole input, exiting if it cannot be read.
+func readAll(r io.Reader) string {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("Failed to read input: %s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	return string(contents)
+}
+
+// parsePlan parses the input, returning nil if it is not a plan.
+func parsePlan(input string) *parser.Plan {
+	return parseChunk(input, true, true)
+}
+
+// parseChunk parses a chunk of a plan read by a parser.ChunkReader, returning
+// nil if it could not be parsed.
+func parseChunk(chunk string, first, last bool) *parser.Plan {
+	plan, err := parser.ParseChunk(chunk, first, last)
+	if err == parser.ErrParseFailure {
+		return nil
+	}
 
-		printer.PrettyPrint(plan)
-	} else {
-		cmd.Usage() // nolint: gosec
+	// plan will be nil if the parser panicked (potentially due to unrecognized
+	// character or sequences).
+	return plan
+}
+
+// passthrough prints the original input of a plan that could not be parsed
+// and exits with the given code.
+func passthrough(input string, code int) {
+	os.Stderr.WriteString(color.RedString("Failed to parse plan. Returning original input.\n")) // nolint: gosec
+	fmt.Println(input)
+	os.Exit(code)
+}
+
+// hyperlinkOptions returns whether hyperlinks should be printed along with the
+// documentation URL templates read from the --docs-links file.
+func hyperlinkOptions() (bool, map[string]string, error) {
+	var enabled bool
+	switch hyperlinks {
+	case "always":
+		enabled = true
+	case "never":
+		enabled = false
+	case "auto":
+		enabled = terminal.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb"
+	default:
+		return false, nil, fmt.Errorf("invalid value %q for --hyperlinks, expected auto, always or never", hyperlinks)
 	}
+
+	if !enabled || docsLinks == "" {
+		return enabled, nil, nil
+	}
+
+	contents, err := ioutil.ReadFile(docsLinks)
+	if err != nil {
+		return false, nil, err
+	}
+
+	templates := map[string]string{}
+	if err := yaml.Unmarshal(contents, &templates); err != nil {
+		return false, nil, fmt.Errorf("%s: %s", docsLinks, err)
+	}
+
+	return enabled, templates, nil
+}
+
+// terminalWidth returns the width of the terminal stdout is attached to, or 0
+// if stdout is not a terminal (e.g. when the output is piped).
+func terminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !terminal.IsTerminal(fd) {
+		return 0
+	}
+
+	w, _, err := terminal.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+
+	return w
 }
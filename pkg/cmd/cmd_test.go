@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// setenv sets (or unsets, given nil) environment variables for the duration
+// of a test and returns a function restoring them.
+func setenv(vars map[string]*string) func() {
+	previous := map[string]*string{}
+	for name, value := range vars {
+		if old, ok := os.LookupEnv(name); ok {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+
+		if value == nil {
+			os.Unsetenv(name) // nolint: errcheck
+		} else {
+			os.Setenv(name, *value) // nolint: errcheck
+		}
+	}
+
+	return func() {
+		for name, value := range previous {
+			if value == nil {
+				os.Unsetenv(name) // nolint: errcheck
+			} else {
+				os.Setenv(name, *value) // nolint: errcheck
+			}
+		}
+	}
+}
+
+func str(v string) *string {
+	return &v
+}
+
+func TestPagerCommand(t *testing.T) {
+	cases := []struct {
+		name         string
+		sceneryPager *string
+		pager        *string
+		expected     []string
+	}{
+		{"defaults to less", nil, nil, []string{"less", "-R"}},
+		{"uses PAGER", nil, str("more -s"), []string{"more", "-s"}},
+		{"prefers SCENERY_PAGER over PAGER", str("most"), str("more"), []string{"most"}},
+		{"does not page through cat", str("cat"), str("more"), nil},
+		{"does not page with an empty SCENERY_PAGER", str(""), str("more"), nil},
+		{"does not page through cat from PAGER", nil, str("cat"), nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setenv(map[string]*string{"SCENERY_PAGER": tc.sceneryPager, "PAGER": tc.pager})()
+
+			assert.Equal(t, tc.expected, pagerCommand())
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	// The repository configuration is looked for up to the root of the
+	// repository
+	repo := filepath.Join(dir, "repo")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, repoConfigName), []byte("width: 100\ntheme: deuteranopia\n"), 0644))
+
+	userConfig := filepath.Join(dir, "config", "scenery", "config.yml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(userConfig), 0755))
+	assert.NoError(t, ioutil.WriteFile(userConfig, []byte("width: 80\ntheme: monochrome\ndiff-context: 3\njobs: 2\n"), 0644))
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(wd) // nolint: errcheck
+	assert.NoError(t, os.Chdir(repo))
+
+	defer setenv(map[string]*string{
+		"XDG_CONFIG_HOME":      str(filepath.Join(dir, "config")),
+		"SCENERY_THEME":        str("high-contrast"),
+		"SCENERY_DIFF_CONTEXT": str("4"),
+		"SCENERY_WIDTH":        nil,
+		"SCENERY_JOBS":         nil,
+	})()
+
+	var width, diffContext, jobs int
+	var theme string
+
+	cmd := &cobra.Command{Use: "scenery", Run: func(*cobra.Command, []string) {}}
+	cmd.PersistentFlags().IntVar(&width, "width", 0, "")
+	cmd.PersistentFlags().IntVar(&diffContext, "diff-context", 5, "")
+	cmd.PersistentFlags().IntVar(&jobs, "jobs", 0, "")
+	cmd.PersistentFlags().StringVar(&theme, "theme", "default", "")
+	assert.NoError(t, cmd.ParseFlags([]string{"--diff-context", "1"}))
+
+	settingSources = map[string]string{}
+	defer func() { settingSources = map[string]string{} }()
+
+	assert.NoError(t, loadConfig(cmd))
+
+	// Flags win over the environment, which wins over the repository
+	// configuration, which wins over the user configuration
+	assert.Equal(t, 1, diffContext)
+	assert.Equal(t, "flag", settingSources["diff-context"])
+
+	assert.Equal(t, "high-contrast", theme)
+	assert.Equal(t, "SCENERY_THEME", settingSources["theme"])
+
+	assert.Equal(t, 100, width)
+	assert.Equal(t, filepath.Join(repo, repoConfigName), settingSources["width"])
+
+	assert.Equal(t, 2, jobs)
+	assert.Equal(t, userConfig, settingSources["jobs"])
+}
+
+func TestLoadConfigRejectsLists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, repoConfigName), []byte("theme: [default, monochrome]\n"), 0644))
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(wd) // nolint: errcheck
+	assert.NoError(t, os.Chdir(dir))
+
+	defer setenv(map[string]*string{"XDG_CONFIG_HOME": str(dir), "SCENERY_THEME": nil})()
+
+	var theme string
+	cmd := &cobra.Command{Use: "scenery"}
+	cmd.PersistentFlags().StringVar(&theme, "theme", "default", "")
+
+	settingSources = map[string]string{}
+	defer func() { settingSources = map[string]string{} }()
+
+	assert.Error(t, loadConfig(cmd))
+	assert.Equal(t, "default", theme)
+}
+
+func TestInputDisabled(t *testing.T) {
+	assert.Equal(t, []string{"HOME=/root", "TF_INPUT=0"}, inputDisabled([]string{"HOME=/root"}))
+	assert.Equal(t, []string{"HOME=/root", "TF_INPUT=0"}, inputDisabled([]string{"TF_INPUT=1", "HOME=/root"}))
+	assert.Equal(t, []string{"TF_INPUT=false"}, inputDisabled([]string{"TF_INPUT=false"}))
+	assert.Equal(t, []string{"TF_INPUT=0"}, inputDisabled([]string{"TF_INPUT=0"}))
+}
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name     string
+		script   string
+		expected int
+	}{
+		{"exits with the code of the command", "exit 2", 2},
+		{"adds the signal to 128", "kill -TERM $$", 128 + int(syscall.SIGTERM)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := exec.Command("sh", "-c", tc.script).Run()
+
+			exitErr, ok := err.(*exec.ExitError)
+			assert.True(t, ok)
+
+			assert.Equal(t, tc.expected, exitCode(exitErr.Sys().(syscall.WaitStatus)))
+		})
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	for _, name := range []string{"envs/production/plan.txt", "envs/staging/b.txt", "envs/staging/a.txt", "envs/staging/.hidden", "single.txt"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "empty"), 0755))
+
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	cases := []struct {
+		name     string
+		args     []string
+		expected []string
+		err      bool
+	}{
+		{"keeps files", join("single.txt"), join("single.txt"), false},
+		{"expands directories", join("envs/staging"), join("envs/staging/a.txt", "envs/staging/b.txt"), false},
+		{"expands globs", join("envs/*/*.txt"), join("envs/production/plan.txt", "envs/staging/a.txt", "envs/staging/b.txt"), false},
+		{"expands directories matched by globs", join("envs/*"), join("envs/production/plan.txt", "envs/staging/a.txt", "envs/staging/b.txt"), false},
+		{"rejects globs matching nothing", join("envs/*.json"), nil, true},
+		{"rejects directories without plans", join("empty"), nil, true},
+		{"rejects missing files", join("missing.txt"), nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			paths, err := expandPaths(tc.args)
+
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, paths)
+		})
+	}
+}
+
+func TestSectionLabel(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		planPath string
+		expected string
+	}{
+		{"uses the directory of the plan file", "plans/prod.txt", "envs/production/plan.tfplan", "envs/production"},
+		{"uses the file without a Path line", "plans/prod.txt", "", "plans/prod.txt"},
+		{"uses the file when the plan was saved in the working directory", "plans/prod.txt", "plan.tfplan", "plans/prod.txt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sectionLabel(tc.path, &parser.Plan{Path: tc.planPath}))
+		})
+	}
+}
+
+func TestParseConcurrently(t *testing.T) {
+	cases := []struct {
+		name    string
+		n       int
+		workers int
+	}{
+		{"uses the number of CPUs by default", 5, 0},
+		{"uses a single worker", 5, 1},
+		{"uses more workers than plans", 2, 8},
+		{"parses no plans", 0, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results := parseConcurrently(tc.n, tc.workers, func(i int) planResult {
+				return planResult{label: fmt.Sprint(i)}
+			})
+
+			assert.Len(t, results, tc.n)
+			for i, r := range results {
+				assert.Equal(t, fmt.Sprint(i), (<-r).label)
+			}
+		})
+	}
+}
+
+func TestPrintModules(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	input, err := ioutil.ReadFile("../../fixtures/rawPlans/terragruntInput.txt")
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("../../fixtures/rawPlans/terragruntOutput.txt")
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	out := &output{w: bufio.NewWriter(&b)}
+
+	assert.True(t, printModules(out, string(input)))
+	out.Flush()
+	assert.Equal(t, string(expected), b.String())
+}
+
+func TestDetectInput(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected inputKind
+	}{
+		{"detects plans", "\nTerraform will perform the following actions:\n", planInput},
+		{"detects the output of Terragrunt", "[terragrunt] 2019/03/04 10:12:01 Running command: terraform plan\n[envs/prod/vpc] Refreshing...\n[envs/prod/app] Refreshing...\n[envs/prod/vpc] \n", modulesInput},
+		{"detects short output of Terragrunt", "[envs/prod/vpc] No changes.\n[envs/prod/app] No changes.\n", modulesInput},
+		{"does not take a single prefixed line for Terragrunt", "[INFO] starting\n  + aws_eip.web\n      id: <computed>\n", planInput},
+		{"detects state", "# aws_instance.web:\nresource \"aws_instance\" \"web\" {\n", stateInput},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, kind, err := detectInput(strings.NewReader(tc.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, kind)
+
+			// The whole input is still read
+			read, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.input, string(read))
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	repoConfigName = ".scenery.yml"
+	envPrefix      = "SCENERY_"
+)
+
+// configFile holds the settings read from a configuration file, keyed by flag
+// name (e.g. `hide-computed: true`).
+type configFile struct {
+	path     string
+	settings map[string]interface{}
+}
+
+// settingSources records where the value of each flag that was not left to
+// its default comes from.
+var settingSources = map[string]string{}
+
+// loadConfig sets the flags that were not passed on the command line from,
+// in order of precedence, SCENERY_* environment variables, the repository
+// configuration file and the user configuration file.
+func loadConfig(cmd *cobra.Command) error {
+	var files []*configFile
+
+	for _, path := range []string{findRepoConfig(), userConfigPath()} {
+		if path == "" {
+			continue
+		}
+
+		f, err := readConfigFile(path)
+		if err != nil {
+			return err
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+
+	for _, f := range files {
+		for name := range f.settings {
+			if flag := cmd.Root().PersistentFlags().Lookup(name); flag == nil {
+				return fmt.Errorf("%s: unknown setting %q", f.path, name)
+			}
+		}
+	}
+
+	var err error
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil {
+			return
+		}
+
+		if flag.Changed {
+			settingSources[flag.Name] = "flag"
+			return
+		}
+
+		env := envVar(flag.Name)
+		if value, ok := os.LookupEnv(env); ok {
+			err = setFlag(flag, value, env)
+			return
+		}
+
+		for _, f := range files {
+			if value, ok := f.settings[flag.Name]; ok {
+				switch value.(type) {
+				case []interface{}, map[interface{}]interface{}:
+					err = fmt.Errorf("%s: invalid value for %s, expected a single value", f.path, flag.Name)
+				default:
+					err = setFlag(flag, fmt.Sprint(value), f.path)
+				}
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+func setFlag(flag *pflag.Flag, value, source string) error {
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("%s: invalid value %q for %s: %s", source, value, flag.Name, err)
+	}
+
+	settingSources[flag.Name] = source
+
+	return nil
+}
+
+// isSet reports whether the flag was set on the command line, through the
+// environment or in a configuration file.
+func isSet(name string) bool {
+	_, ok := settingSources[name]
+	return ok
+}
+
+// envVar returns the environment variable setting the flag, e.g.
+// SCENERY_HIDE_COMPUTED for --hide-computed.
+func envVar(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// findRepoConfig looks for a repository configuration file in the working
+// directory and its parents, stopping at the root of the git repository.
+func findRepoConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, repoConfigName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// userConfigPath returns $XDG_CONFIG_HOME/scenery/config.yml, defaulting
+// XDG_CONFIG_HOME to ~/.config.
+func userConfigPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "scenery", "config.yml")
+}
+
+// readConfigFile parses the configuration file at path. A missing file is not
+// an error and results in a nil configFile.
+func readConfigFile(path string) (*configFile, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &settings); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return &configFile{path: path, settings: settings}, nil
+}
+
+// runConfigShow prints the effective value of every setting along with where
+// it comes from.
+func runConfigShow(cmd *cobra.Command, args []string) {
+	var flags []*pflag.Flag
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		flags = append(flags, flag)
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, flag := range flags {
+		source, ok := settingSources[flag.Name]
+		if !ok {
+			source = "default"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t(%s)\n", flag.Name, flag.Value, source)
+	}
+	w.Flush() // nolint: gosec
+}
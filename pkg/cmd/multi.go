@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/dmlittle/scenery/pkg/printer"
+	"github.com/fatih/color"
+)
+
+// planResult is the outcome of reading and parsing one of several plans.
+type planResult struct {
+	label string
+	input string
+	plan  *parser.Plan
+	err   error
+}
+
+// isMultiplePlans reports whether the argument designates several plans, i.e.
+// is a directory or a glob pattern.
+func isMultiplePlans(arg string) bool {
+	if strings.ContainsAny(arg, "*?[") {
+		return true
+	}
+
+	stat, err := os.Stat(arg)
+	return err == nil && stat.IsDir()
+}
+
+// expandPaths returns the plan files designated by the arguments, expanding
+// directories, including those matched by glob patterns, to the files they
+// contain and glob patterns to the files they match.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			files, err := expandPath(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, files...)
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no plans match %q", arg)
+		}
+
+		for _, match := range matches {
+			files, err := expandPath(match)
+			if err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, files...)
+		}
+	}
+
+	return paths, nil
+}
+
+// expandPath returns the plan file designated by the path, or the files the
+// path contains if it is a directory.
+func expandPath(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stat.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if f.Mode().IsRegular() && !strings.HasPrefix(f.Name(), ".") {
+			names = append(names, filepath.Join(path, f.Name()))
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no plans in %s", path)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// parseConcurrently runs parse for each of the n plans with the given number
+// of workers (the number of CPUs if not positive). The results are sent on
+// the returned channels, in order, as soon as they are ready.
+func parseConcurrently(n, workers int, parse func(i int) planResult) []chan planResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+
+	results := make([]chan planResult, n)
+	for i := range results {
+		results[i] = make(chan planResult, 1)
+	}
+
+	jobs := make(chan int, n)
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				results[i] <- parse(i)
+			}
+		}()
+	}
+
+	return results
+}
+
+func parsePlanFile(path string) planResult {
+	result := planResult{label: path}
+
+	contents, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	result.input = string(contents)
+	result.plan = parsePlan(result.input)
+	if result.plan != nil {
+		result.label = sectionLabel(path, result.plan)
+	}
+
+	return result
+}
+
+// runPlans prints the plans read from the files, directories and glob
+// patterns given as arguments.
+func runPlans(args []string) {
+	paths, err := expandPaths(args)
+	if err != nil {
+		os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+		os.Exit(1)
+	}
+
+	out := newOutput()
+	ok := printPlans(out, parseConcurrently(len(paths), jobs, func(i int) planResult {
+		return parsePlanFile(paths[i])
+	}))
+	out.Close()
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// runModules prints the plans of each module found in the interleaved output
+// of a Terragrunt `run-all plan` command.
+func runModules(input string) {
+	out := newOutput()
+	ok := printModules(out, input)
+	out.Close()
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// printModules prints the plan of each module of the output of a Terragrunt
+// `run-all plan` command.
+func printModules(out *output, input string) bool {
+	modules := parser.SplitModules(input)
+
+	return printPlans(out, parseConcurrently(len(modules), jobs, func(i int) planResult {
+		return planResult{
+			label: modules[i].Module,
+			input: modules[i].Output,
+			plan:  parsePlan(modules[i].Output),
+		}
+	}))
+}
+
+// printPlans prints several plans, each under a heading, followed by the
+// total of their changes. Plans that cannot be read or parsed are reported
+// and printed as-is without preventing the others from being printed. It
+// returns false if any plan could not be read or parsed, or if --strict was
+// passed and a summary does not match the resources of its plan.
+func printPlans(out *output, results []chan planResult) bool {
+	var plans []*parser.Plan
+	unread, unparsed := 0, 0
+	mismatch := false
+
+	for i, r := range results {
+		result := <-r
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		switch {
+		case result.err != nil:
+			printer.FprintSection(out, result.label)
+			out.Flush()
+			os.Stderr.WriteString(color.RedString("Failed to read %s: %s\n", result.label, result.err)) // nolint: gosec
+			unread++
+		case result.plan == nil:
+			printer.FprintSection(out, result.label)
+			out.Flush()
+			os.Stderr.WriteString(color.RedString("Failed to parse %s. Returning original input.\n", result.label)) // nolint: gosec
+			fmt.Fprintln(out, strings.TrimRight(result.input, "\n"))
+			unparsed++
+		default:
+			// Plans start with a blank line when they have no changes and end
+			// with one when they have no summary, which are trimmed to keep
+			// sections evenly spaced.
+			var b bytes.Buffer
+			printer.Fprint(&b, result.plan)
+
+			printer.FprintSection(out, result.label)
+			fmt.Fprintln(out, strings.Trim(b.String(), "\n"))
+			plans = append(plans, result.plan)
+			mismatch = mismatch || result.plan.CheckSummary() != nil
+		}
+
+		out.Flush()
+	}
+
+	fmt.Fprintln(out)
+	printer.FprintTotal(out, plans, unread, unparsed)
+
+	return unread == 0 && unparsed == 0 && !(strict && mismatch)
+}
+
+// sectionLabel returns the heading of a plan: the directory of the plan file
+// reported by Terraform on its `Path:` line (i.e. the working directory the
+// plan was saved from), or else the file the plan was read from.
+func sectionLabel(path string, plan *parser.Plan) string {
+	if plan.Path != "" {
+		if dir := filepath.Dir(plan.Path); dir != "." {
+			return dir
+		}
+	}
+
+	return path
+}
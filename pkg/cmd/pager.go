@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const defaultPager = "less -R"
+
+// pagerCommand returns the pager output should be piped through, taken from
+// SCENERY_PAGER or PAGER. An empty result means output should not be paged.
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("SCENERY_PAGER")
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		pager = defaultPager
+	}
+
+	fields := strings.Fields(pager)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+
+	return fields
+}
+
+// output writes to stdout, through a pager once the output does not fit in
+// the terminal. Output is held back until then so that short output is
+// printed directly.
+type output struct {
+	height int
+	held   bytes.Buffer
+	lines  int
+
+	w     *bufio.Writer
+	pipe  io.WriteCloser
+	pager *exec.Cmd
+}
+
+// newOutput returns the output to print to. Output is only paged when stdout
+// is a terminal and --no-pager was not passed.
+func newOutput() *output {
+	o := &output{}
+
+	fd := int(os.Stdout.Fd())
+	if noPager || pagerCommand() == nil || !terminal.IsTerminal(fd) {
+		o.w = bufio.NewWriter(os.Stdout)
+		return o
+	}
+
+	_, height, err := terminal.GetSize(fd)
+	if err != nil {
+		o.w = bufio.NewWriter(os.Stdout)
+		return o
+	}
+	o.height = height
+
+	return o
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	if o.w != nil {
+		return o.w.Write(p)
+	}
+
+	o.held.Write(p) // nolint: gosec
+	o.lines += bytes.Count(p, []byte("\n"))
+
+	if o.lines >= o.height {
+		o.startPager()
+	}
+
+	return len(p), nil
+}
+
+// startPager starts the pager and sends it the output held so far. Output is
+// printed directly if the pager cannot be started.
+func (o *output) startPager() {
+	pager := pagerCommand()
+
+	c := exec.Command(pager[0], pager[1:]...) // nolint: gosec
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	// Like git, let less quit when the output fits on one screen, keep colors
+	// and leave the output on the screen when exiting.
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		c.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	pipe, err := c.StdinPipe()
+	if err == nil {
+		err = c.Start()
+	}
+
+	if err != nil {
+		o.w = bufio.NewWriter(os.Stdout)
+	} else {
+		o.pager = c
+		o.pipe = pipe
+		o.w = bufio.NewWriter(pipe)
+	}
+
+	o.w.Write(o.held.Bytes()) // nolint: gosec
+	o.held.Reset()
+}
+
+// Flush sends the output written so far to stdout or to the pager, unless it
+// is being held back.
+func (o *output) Flush() {
+	if o.w != nil {
+		o.w.Flush() // nolint: gosec
+	}
+}
+
+// Close prints the output held back, if any, and waits for the pager to be
+// quit.
+func (o *output) Close() {
+	if o.w == nil {
+		os.Stdout.Write(o.held.Bytes()) // nolint: gosec
+		return
+	}
+
+	o.Flush()
+
+	if o.pager != nil {
+		o.pipe.Close() // nolint: gosec, errcheck
+
+		// Errors from the pager itself (e.g. being quit before reading all
+		// of the output) are not worth reporting.
+		o.pager.Wait() // nolint: gosec, errcheck
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/dmlittle/scenery/pkg/printer"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// runCommand runs the command given as arguments (e.g. `terraform plan`),
+// letting its stderr through and prettifying its stdout.
+// scenery exits with the exit code of the command so that `-detailed-exitcode`
+// keeps working.
+func runCommand(cmd *cobra.Command, args []string) {
+	setOptions()
+
+	var stdout bytes.Buffer
+
+	c := exec.Command(args[0], args[1:]...) // nolint: gosec
+	c.Stdin = os.Stdin
+	// The output of the command is only printed once it exited, so prompts
+	// (e.g. for missing variables) would never be seen
+	c.Env = inputDisabled(os.Environ())
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+
+	// Interrupts are sent to the whole process group so the command receives
+	// them as well. Wait for it to exit (terraform cleans up and releases its
+	// state lock) and print whatever it output.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	code := 0
+	if err := c.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			os.Stderr.WriteString(color.RedString("%s\n", err)) // nolint: gosec
+			os.Exit(1)
+		}
+
+		code = 1
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			code = exitCode(status)
+		}
+	}
+
+	input := strings.TrimRight(stdout.String(), "\n")
+	if input == "" {
+		os.Exit(code)
+	}
+
+	plan := parsePlan(input)
+	if plan == nil {
+		// A failed command has already explained itself on stderr
+		if code != 0 {
+			fmt.Println(input)
+			os.Exit(code)
+		}
+
+		passthrough(input, code)
+		return
+	}
+
+	out := newOutput()
+	printer.Fprint(out, plan)
+	out.Close()
+
+	if strict && plan.CheckSummary() != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(code)
+}
+
+// inputDisabled returns the environment with Terraform's interactive input
+// disabled, i.e. with TF_INPUT=0 unless it is already set to 0 or false.
+func inputDisabled(env []string) []string {
+	disabled := make([]string, 0, len(env)+1)
+	for _, v := range env {
+		switch {
+		case v == "TF_INPUT=0" || v == "TF_INPUT=false":
+			return env
+		case !strings.HasPrefix(v, "TF_INPUT="):
+			disabled = append(disabled, v)
+		}
+	}
+
+	return append(disabled, "TF_INPUT=0")
+}
+
+// exitCode returns the exit code of a command, which is 128 plus the number
+// of the signal for commands killed by a signal like shells do.
+func exitCode(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return status.ExitStatus()
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Symbols of the changes of resources moved, imported or forgotten by
+// Terraform 1.1 and later, which Terraform 0.11 plans have no symbol for.
+const (
+	MoveChange   = ">"
+	ImportChange = "<-"
+	ForgetChange = "."
+)
+
+var (
+	// actionHeaderRE matches the line introducing each resource of the plans
+	// of Terraform 0.12 and later, e.g. `# aws_instance.web will be updated
+	// in-place` or `# aws_instance.a has moved to aws_instance.b`.
+	actionHeaderRE = regexp.MustCompile(`^\s*# (\S+) (has moved to (\S+)|will be (?:created|destroyed|updated in-place|read during apply|imported)|will be replaced(?:, as requested| due to changes in replace_triggered_by)|(?:is tainted, so )?must be replaced|will no longer be managed by Terraform|will be removed from (?:the )?state.*)$`)
+
+	// actionNoteRE matches the notes below the header of a resource, e.g.
+	// `# (because aws_instance.web is not in configuration)`.
+	actionNoteRE = regexp.MustCompile(`^# \((.+)\)$`)
+
+	// movedFromRE and importedFromRE match the notes of resources moved or
+	// imported along with another change, e.g. `moved from aws_instance.a`
+	// or `imported from "i-0123456789abcdef0"`.
+	movedFromRE    = regexp.MustCompile(`^moved from (\S+)$`)
+	importedFromRE = regexp.MustCompile(`^imported from "(.*)"$`)
+)
+
+// splitActions separates the resources displayed the way Terraform 0.12 and
+// later does, i.e. as diffs of their configuration below a header describing
+// the change, from the rest of the plan. The attributes of each resource are
+// parsed like the changes made outside of Terraform.
+func splitActions(planText string) (string, []*Resource) {
+	if !strings.Contains(planText, "# ") {
+		return planText, nil
+	}
+
+	var resources []*Resource
+	var rest []string
+	var body *diffBody
+
+	for _, line := range strings.Split(planText, "\n") {
+		plain := ansiRE.ReplaceAllString(line, "")
+
+		if m := actionHeaderRE.FindStringSubmatch(plain); m != nil {
+			r := &Resource{Header: actionHeader(m[1], m[2], m[3])}
+			resources = append(resources, r)
+			body = newDiffBody(r)
+			continue
+		}
+
+		if body == nil {
+			rest = append(rest, line)
+			continue
+		}
+
+		if !body.opened {
+			trimmed := strings.TrimSpace(plain)
+
+			if m := actionNoteRE.FindStringSubmatch(trimmed); m != nil {
+				actionNote(body.r.Header, m[1])
+				continue
+			}
+
+			switch {
+			case trimmed == "":
+				continue
+			case !driftResourceRE.MatchString(trimmed):
+				// Resources without a configuration end with their header
+				body = nil
+				rest = append(rest, line)
+				continue
+			}
+		}
+
+		if body.parseLine(plain) {
+			body = nil
+		}
+	}
+
+	return strings.Join(rest, "\n"), resources
+}
+
+// actionHeader returns the header of a resource from the way Terraform
+// describes its change, e.g. `has moved to aws_instance.b`.
+func actionHeader(address, description, movedTo string) *Header {
+	h := &Header{Name: &address}
+
+	var change string
+	switch {
+	case movedTo != "":
+		change = MoveChange
+		h.Name, h.MovedFrom = &movedTo, address
+	case description == "will be created":
+		change = "+"
+	case description == "will be destroyed":
+		change = "-"
+	case description == "will be updated in-place":
+		change = "~"
+	case description == "will be read during apply":
+		change = "<="
+	case description == "will be imported":
+		change = ImportChange
+	case strings.HasPrefix(description, "will be replaced") || strings.HasSuffix(description, "must be replaced"):
+		change = "-/+"
+		h.NewResource = true
+		h.Taint = strings.HasPrefix(description, "is tainted")
+	default:
+		change = ForgetChange
+	}
+
+	h.Change = &change
+	return h
+}
+
+// actionNote records the note below the header of a resource moved or
+// imported along with another change, e.g. `moved from aws_instance.a`.
+// Other notes, such as the reason a resource is destroyed, are ignored.
+func actionNote(h *Header, note string) {
+	if m := movedFromRE.FindStringSubmatch(note); m != nil {
+		h.MovedFrom = m[1]
+	}
+	if m := importedFromRE.FindStringSubmatch(note); m != nil {
+		h.ImportedFrom = m[1]
+	}
+}
@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Apply is the parsed output of `terraform apply` (or `terraform destroy`).
+type Apply struct {
+	// Resources are listed in the order Terraform started applying them.
+	Resources []*AppliedResource
+
+	// Errors are the errors that could not be attributed to a resource.
+	Errors []string
+
+	Summary *ApplySummary
+}
+
+// AppliedResource records the change applied to a resource.
+type AppliedResource struct {
+	Address string
+
+	// Change uses the same symbols as Header.Change, resources that were
+	// destroyed and created again being recorded as replaced ("-/+").
+	Change string
+
+	ID string
+
+	// Elapsed is the time the change took or, if it did not complete, the
+	// time it had taken when Terraform last reported on it.
+	Elapsed  time.Duration
+	Complete bool
+	Errors   []string
+}
+
+// ApplySummary is the summary printed by Terraform once the apply is over.
+//
+// Example:
+//
+//	`Apply complete! Resources: 1 added, 0 changed, 0 destroyed.`
+//
+// Verb is "Apply", or "Destroy" for `terraform destroy`, whose summary only
+// counts the resources destroyed.
+type ApplySummary struct {
+	Verb      string
+	Added     int
+	Changed   int
+	Destroyed int
+}
+
+var (
+	// Terraform 0.11 reports IDs and elapsed times in parentheses, e.g.
+	// `aws_eip.web: Creation complete after 2s (ID: eipalloc-2e0f8f15)`, and
+	// later versions in brackets, e.g. `... after 2s [id=eipalloc-2e0f8f15]`.
+	applyStartRE    = regexp.MustCompile(`^(\S+): (Creating|Modifying|Destroying|Reading)\.\.\.(?: [\[(](?:ID: |id=)([^\])]+)[\])])?`)
+	applyProgressRE = regexp.MustCompile(`^(\S+): Still (?:creating|modifying|destroying|reading)\.\.\. [\[(](?:(?:ID: |id=)[^,]+, )?(\S+) elapsed[\])]`)
+	applyCompleteRE = regexp.MustCompile(`^(\S+): (?:Creation|Modifications|Destruction|Read) complete after (\S+)(?: [\[(](?:ID: |id=)([^\])]+)[\])])?`)
+	applySummaryRE  = regexp.MustCompile(`^(Apply|Destroy) complete! Resources: (.+)\.`)
+	applyCountRE    = regexp.MustCompile(`(\d+) (added|changed|destroyed)`)
+
+	// Terraform 0.11 lists errors by resource, e.g.
+	// `* aws_instance.web: Error launching source instance: ...`, whereas
+	// later versions point to the resource in the configuration below the
+	// error, e.g. `  on main.tf line 1, in resource "aws_instance" "web":`.
+	applyResourceErrorRE = regexp.MustCompile(`^\* (\S+): (.+)`)
+	applyErrorRE         = regexp.MustCompile(`^Error: (.+)`)
+	applyErrorSourceRE   = regexp.MustCompile(`^\s+on .+, in (?:resource|data) "([^"]+)" "([^"]+)":`)
+	applyErrorCountRE    = regexp.MustCompile(`^\d+ error\(s\) occurred:?$`)
+)
+
+// applyChanges maps the verbs Terraform uses when starting to apply a change
+// to the symbols of the change.
+var applyChanges = map[string]string{
+	"Creating":   "+",
+	"Modifying":  "~",
+	"Destroying": "-",
+	"Reading":    "<=",
+}
+
+// ParseApply takes in the output of `terraform apply` and returns the changes
+// that were applied to each resource along with their timings and errors.
+// Lines that are not about applying changes, such as the plan preceding them,
+// are ignored.
+//
+// The ErrParseFailure error is returned if the output does not mention
+// applying any change.
+func ParseApply(input string) (*Apply, error) {
+	a := &Apply{}
+	resources := map[string]*AppliedResource{}
+
+	// Elapsed times of the first half of replacements, which later progress
+	// reports do not include.
+	previous := map[*AppliedResource]time.Duration{}
+
+	// Errors of Terraform 0.12 and later are only attributed once the
+	// location of the error in the configuration is read.
+	var pending []string
+	flush := func() {
+		a.Errors = append(a.Errors, pending...)
+		pending = nil
+	}
+
+	for _, line := range strings.Split(ansiRE.ReplaceAllString(input, ""), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if m := applyStartRE.FindStringSubmatch(line); m != nil {
+			change := applyChanges[m[2]]
+
+			r, ok := resources[m[1]]
+			switch {
+			case !ok:
+				r = &AppliedResource{Address: m[1], Change: change}
+				resources[m[1]] = r
+				a.Resources = append(a.Resources, r)
+			case (r.Change == "-" && change == "+") || (r.Change == "+" && change == "-"):
+				r.Change = "-/+"
+				r.Complete = false
+				previous[r] = r.Elapsed
+			}
+
+			if m[3] != "" {
+				r.ID = m[3]
+			}
+			continue
+		}
+
+		if m := applyProgressRE.FindStringSubmatch(line); m != nil {
+			if r, ok := resources[m[1]]; ok {
+				if d, err := time.ParseDuration(m[2]); err == nil {
+					r.Elapsed = previous[r] + d
+				}
+			}
+			continue
+		}
+
+		if m := applyCompleteRE.FindStringSubmatch(line); m != nil {
+			r, ok := resources[m[1]]
+			if !ok {
+				continue
+			}
+
+			if d, err := time.ParseDuration(m[2]); err == nil {
+				r.Elapsed = previous[r] + d
+			}
+			if m[3] != "" {
+				r.ID = m[3]
+			}
+			r.Complete = true
+			continue
+		}
+
+		if m := applySummaryRE.FindStringSubmatch(line); m != nil {
+			a.Summary = parseApplySummary(m[1], m[2])
+			continue
+		}
+
+		if m := applyResourceErrorRE.FindStringSubmatch(line); m != nil {
+			if applyErrorCountRE.MatchString(m[2]) {
+				continue
+			}
+
+			if r, ok := resources[m[1]]; ok {
+				r.Errors = append(r.Errors, m[2])
+			} else {
+				a.Errors = append(a.Errors, m[1]+": "+m[2])
+			}
+			continue
+		}
+
+		if m := applyErrorRE.FindStringSubmatch(line); m != nil {
+			flush()
+
+			// Terraform 0.11 introduces the errors listed by resource with
+			// this one.
+			if m[1] != "Error applying plan:" {
+				pending = append(pending, m[1])
+			}
+			continue
+		}
+
+		if m := applyErrorSourceRE.FindStringSubmatch(line); m != nil && len(pending) > 0 {
+			if r := findAppliedResource(a.Resources, m[1]+"."+m[2]); r != nil {
+				r.Errors = append(r.Errors, pending...)
+				pending = nil
+			}
+		}
+	}
+	flush()
+
+	if len(a.Resources) == 0 && a.Summary == nil && len(a.Errors) == 0 {
+		return nil, ErrParseFailure
+	}
+
+	return a, nil
+}
+
+// findAppliedResource returns the resource with the given address, ignoring
+// modules, preferring resources whose change did not complete.
+func findAppliedResource(resources []*AppliedResource, address string) *AppliedResource {
+	var found *AppliedResource
+
+	for _, r := range resources {
+		if r.Address != address && !strings.HasSuffix(r.Address, "."+address) {
+			continue
+		}
+
+		if !r.Complete {
+			return r
+		}
+		if found == nil {
+			found = r
+		}
+	}
+
+	return found
+}
+
+func parseApplySummary(verb, counts string) *ApplySummary {
+	s := &ApplySummary{Verb: verb}
+
+	for _, m := range applyCountRE.FindAllStringSubmatch(counts, -1) {
+		n, _ := strconv.Atoi(m[1]) // nolint: gosec
+
+		switch m[2] {
+		case "added":
+			s.Added = n
+		case "changed":
+			s.Changed = n
+		case "destroyed":
+			s.Destroyed = n
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,361 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// driftStartRE matches the note introducing the changes Terraform (0.15.4
+	// and later) detected outside of Terraform, e.g.
+	// `Note: Objects have changed outside of Terraform`.
+	driftStartRE = regexp.MustCompile(`(?m)^.*Objects have changed outside of Terraform.*$`)
+
+	// driftEndRE matches the lines following the changes made outside of
+	// Terraform.
+	driftEndRE = regexp.MustCompile(`(?m)^\s*(?:Unless you have made equivalent changes|This is a refresh-only plan|Terraform used the selected providers|Terraform will perform the following actions|Resource actions are indicated|No changes\.|─+$|-{8,}$)`)
+
+	// driftHeaderRE matches the line introducing each resource changed outside
+	// of Terraform, e.g. `# aws_instance.web has been changed`.
+	driftHeaderRE = regexp.MustCompile(`^# (\S+) has (?:been )?(changed|deleted)`)
+
+	// driftResourceRE matches the opening of the resource below its header,
+	// e.g. `~ resource "aws_instance" "web" {`.
+	driftResourceRE = regexp.MustCompile(`^(?:(?:[~+.-]|-/\+|\+/-|<=) )?(?:resource|data) "[^"]*" "[^"]*" \{$`)
+
+	driftBlockRE = regexp.MustCompile(`^([a-zA-Z0-9_-]+) \{$`)
+
+	// collectionToValueRE matches the closing line of a collection replaced
+	// by a value, e.g. `] -> "s3:GetObject"`.
+	collectionToValueRE = regexp.MustCompile(`^[\]}] -> (.+?),?$`)
+)
+
+// Values of the plans of Terraform 0.12 and later are displayed differently
+// from those of Terraform 0.11.
+const (
+	knownAfterApply   = "(known after apply)"
+	computedValue     = "<computed>"
+	forcesReplacement = "# forces replacement"
+)
+
+// driftChanges maps the way Terraform describes changes made outside of
+// Terraform to the symbols of the changes.
+var driftChanges = map[string]string{
+	"changed": "~",
+	"deleted": "-",
+}
+
+// splitDrift separates the changes made outside of Terraform from the rest of
+// the plan.
+func splitDrift(planText string) (string, string) {
+	if !strings.Contains(planText, "outside of Terraform") {
+		return "", planText
+	}
+
+	plain := ansiRE.ReplaceAllString(planText, "")
+
+	start := driftStartRE.FindStringIndex(plain)
+	if start == nil {
+		return "", planText
+	}
+
+	end := len(plain)
+	if loc := driftEndRE.FindStringIndex(plain[start[1]:]); loc != nil {
+		end = start[1] + loc[0]
+	}
+
+	return plain[start[1]:end], plain[:start[0]] + plain[end:]
+}
+
+// driftFrame is a map, list, block or JSON value that attributes are nested
+// in. Nested blocks are numbered like in the state (see StateResource).
+type driftFrame struct {
+	key    string
+	list   bool
+	index  int
+	blocks map[string]int
+}
+
+// parseDrift parses the resources changed outside of Terraform. Changes are
+// displayed as diffs of the configuration of the resources, which are
+// flattened to dotted keys like the attributes of Terraform 0.11 plans.
+func parseDrift(driftText string) []*Resource {
+	var resources []*Resource
+	var body *diffBody
+
+	for _, line := range strings.Split(driftText, "\n") {
+		if m := driftHeaderRE.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			change, name := driftChanges[m[2]], m[1]
+			r := &Resource{Header: &Header{Change: &change, Name: &name}}
+			resources = append(resources, r)
+			body = newDiffBody(r)
+			continue
+		}
+
+		if body != nil {
+			body.parseLine(line)
+		}
+	}
+
+	return resources
+}
+
+// diffBody parses the configuration of a resource displayed by Terraform 0.12
+// and later, e.g. `~ resource "aws_instance" "web" { ... }`, a line at a time
+// into the attributes of the resource.
+type diffBody struct {
+	r       *Resource
+	stack   []*driftFrame
+	blocks  map[string]int
+	heredoc *diffHeredoc
+
+	// opened is set once the opening line of the resource has been read.
+	opened bool
+}
+
+// diffHeredoc is a heredoc string being read, e.g. `~ user_data = <<-EOT`,
+// whose lines are marked with + or - in a column of their own when they
+// changed.
+type diffHeredoc struct {
+	key         string
+	marker      string
+	delimiter   string
+	column      int
+	newResource bool
+
+	before []string
+	after  []string
+}
+
+func newDiffBody(r *Resource) *diffBody {
+	return &diffBody{r: r, blocks: map[string]int{}}
+}
+
+// parseLine parses a line of the configuration of the resource. It returns
+// true once the closing brace of the resource has been read.
+func (b *diffBody) parseLine(line string) bool {
+	if b.heredoc != nil {
+		b.parseHeredocLine(line)
+		return false
+	}
+
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	line = strings.TrimSpace(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+	if driftResourceRE.MatchString(line) {
+		b.opened = true
+		return false
+	}
+
+	marker := ""
+	if len(line) > 2 && strings.ContainsAny(line[:1], "~+-") && line[1] == ' ' {
+		marker, line = line[:1], strings.TrimSpace(line[2:])
+		indent += 2
+	}
+
+	// The values of resources being created or read are all added, which
+	// Terraform 0.11 displays as plain values
+	if marker == "+" && (*b.r.Header.Change == "+" || *b.r.Header.Change == "<=") {
+		marker = ""
+	}
+
+	newResource := false
+	if strings.HasSuffix(line, forcesReplacement) {
+		newResource = true
+		line = strings.TrimSpace(strings.TrimSuffix(line, forcesReplacement))
+	}
+
+	switch strings.TrimSuffix(line, ",") {
+	case "}", "]", ")":
+		if len(b.stack) == 0 {
+			return b.opened
+		}
+		b.stack = b.stack[:len(b.stack)-1]
+		return false
+	case "{", "[":
+		// Anonymous collections, e.g. the object of `jsonencode(` or the
+		// elements of a list, are keyed by their index in lists only
+		frame := &driftFrame{list: strings.HasPrefix(line, "["), blocks: map[string]int{}}
+		if len(b.stack) > 0 && b.stack[len(b.stack)-1].list {
+			parent := b.stack[len(b.stack)-1]
+			frame.key = strconv.Itoa(parent.index)
+			parent.index++
+		}
+		b.stack = append(b.stack, frame)
+		return false
+	}
+
+	// Collections replacing a value, e.g. `[ ... ] -> "s3:GetObject"`
+	if m := collectionToValueRE.FindStringSubmatch(line); m != nil && len(b.stack) > 0 {
+		key := b.key("")
+		b.stack = b.stack[:len(b.stack)-1]
+		b.r.Attributes = append(b.r.Attributes, driftAttribute(key, "+", m[1]))
+		return false
+	}
+
+	var key, value string
+	if len(b.stack) > 0 && b.stack[len(b.stack)-1].list {
+		// Elements of lists
+		frame := b.stack[len(b.stack)-1]
+		key, value = strconv.Itoa(frame.index), line
+		frame.index++
+	} else if m := driftBlockRE.FindStringSubmatch(line); m != nil {
+		counts := b.blocks
+		if len(b.stack) > 0 {
+			counts = b.stack[len(b.stack)-1].blocks
+		}
+
+		b.stack = append(b.stack, &driftFrame{key: m[1] + "." + strconv.Itoa(counts[m[1]]), blocks: map[string]int{}})
+		counts[m[1]]++
+		return false
+	} else {
+		key, value = splitStateEntry(line)
+	}
+
+	value = strings.TrimSuffix(strings.TrimSpace(value), ",")
+
+	// Values replaced by a collection, e.g. `"s3:GetObject" -> [`, are
+	// removed before the elements of the collection are added
+	if i := strings.Index(value, " -> "); i >= 0 && (strings.HasSuffix(value, "{") || strings.HasSuffix(value, "[")) {
+		b.r.Attributes = append(b.r.Attributes, driftAttribute(b.key(key), "-", value[:i]))
+		value = value[i+4:]
+	}
+
+	switch {
+	case strings.HasSuffix(value, "{") || value == "jsonencode(":
+		b.stack = append(b.stack, &driftFrame{key: key, blocks: map[string]int{}})
+		return false
+	case strings.HasSuffix(value, "["):
+		b.stack = append(b.stack, &driftFrame{key: key, list: true, blocks: map[string]int{}})
+		return false
+	}
+
+	key = b.key(key)
+
+	if m := stateHeredocRE.FindStringSubmatch(value); m != nil {
+		// The lines of heredocs are marked two columns to the right of the
+		// key
+		b.heredoc = &diffHeredoc{key: key, marker: marker, delimiter: m[2], column: indent + 2, newResource: newResource}
+		return false
+	}
+
+	a := driftAttribute(key, marker, value)
+	a.NewResource = newResource
+	b.r.Attributes = append(b.r.Attributes, a)
+
+	return false
+}
+
+// key returns the dotted key of an attribute nested in the current frames.
+func (b *diffBody) key(key string) string {
+	var keys []string
+	for _, frame := range append(b.stack, &driftFrame{key: key}) {
+		if frame.key != "" {
+			keys = append(keys, frame.key)
+		}
+	}
+
+	return strings.Join(keys, ".")
+}
+
+func (b *diffBody) parseHeredocLine(line string) {
+	h := b.heredoc
+
+	if strings.TrimSpace(line) == h.delimiter {
+		b.heredoc = nil
+		b.r.Attributes = append(b.r.Attributes, h.attribute())
+		return
+	}
+
+	marker, text := "", ""
+	if len(line) > h.column+1 && strings.ContainsAny(line[h.column:h.column+1], "+-") && line[h.column+1] == ' ' {
+		marker = line[h.column : h.column+1]
+	}
+	if len(line) > h.column+2 {
+		text = line[h.column+2:]
+	}
+
+	if marker != "+" {
+		h.before = append(h.before, text)
+	}
+	if marker != "-" {
+		h.after = append(h.after, text)
+	}
+}
+
+// attribute returns the attribute holding the heredoc once it has been read.
+func (h *diffHeredoc) attribute() *Attribute {
+	a := &Attribute{Key: &h.key, NewResource: h.newResource}
+
+	before := unescapeTemplate(strings.Join(h.before, "\n") + "\n")
+	after := unescapeTemplate(strings.Join(h.after, "\n") + "\n")
+
+	switch h.marker {
+	case "":
+		a.Value = &after
+		return a
+	case "+":
+		before = ""
+	case "-":
+		after = ""
+	}
+
+	a.Before, a.After = &before, &after
+	return a
+}
+
+// driftAttribute returns the attribute with the given value, e.g. `"a" ->
+// "b"`, added (+), removed (-) or changed (~). Values only known after apply
+// are computed like in Terraform 0.11 plans.
+func driftAttribute(key, marker, value string) *Attribute {
+	a := &Attribute{Key: &key}
+
+	var before, after string
+	if i := strings.Index(value, " -> "); i >= 0 {
+		before, after = driftValue(value[:i]), driftValue(value[i+4:])
+	} else {
+		switch marker {
+		case "+":
+			after = driftValue(value)
+		case "-":
+			before = driftValue(value)
+		default:
+			v := driftValue(value)
+			if v == computedValue {
+				a.Computed = &v
+			} else {
+				a.Value = &v
+			}
+			return a
+		}
+	}
+
+	a.Before = &before
+	if after == computedValue {
+		a.AfterComputed = &after
+	} else {
+		a.After = &after
+	}
+	return a
+}
+
+func driftValue(value string) string {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case value == "null":
+		return ""
+	case value == stateSensitive:
+		return "<sensitive>"
+	case value == knownAfterApply:
+		return computedValue
+	case strings.HasPrefix(value, `"`):
+		return unquoteState(value)
+	}
+
+	return value
+}
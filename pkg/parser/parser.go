@@ -3,6 +3,8 @@ package parser
 import (
 	"errors"
 	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/alecthomas/participle"
 )
@@ -18,19 +20,38 @@ type Plan struct {
 	_         *string     `parser:"{\"\\n\"}"`
 
 	NoChanges bool
+
+	// Path is the path of the plan file given by Terraform (e.g. when run
+	// with `-out`), if any.
+	Path string
+
+	// Destroy is set when Terraform asked for confirmation to destroy all
+	// resources, i.e. for plans printed by `terraform destroy`.
+	Destroy bool
+
+	// Drift lists the resources Terraform detected had changed outside of
+	// Terraform, the only resources of refresh-only plans.
+	Drift []*Resource
 }
 
 // The Metadata struct is responsible for parsing the plan metadata that
 // displays the summary statistics from the Terraform plan output.
 //
-// Example:
-//   `Plan: 2 to add, 0 to change, 0 to destroy.`
+// Examples:
+//
+//	`Plan: 2 to add, 0 to change, 0 to destroy.`
+//	`Plan: 1 to import, 0 to add, 0 to change, 0 to destroy, 1 to forget.`
 type Metadata struct {
 	_       *string `parser:"\"Plan\" \":\""`
+	Import  int     `parser:"{ @Int \"to\" \"import\" \",\" }"`
 	Add     int     `parser:"@Int \"to\" \"add\" \",\""`
 	Change  int     `parser:"@Int \"to\" \"change\" \",\" "`
-	Destroy int     `parser:"@Int \"to\" \"destroy\" \".\" "`
+	Destroy int     `parser:"@Int \"to\" \"destroy\""`
+	Forget  int     `parser:"{ \",\" @Int \"to\" \"forget\" } \".\""`
 	_       *string `parser:"{\"\\n\"}"`
+
+	// Move counts the resources moved, which Terraform does not summarise.
+	Move int
 }
 
 // The Resource struct is responsible for parsing each resource group that is
@@ -46,32 +67,43 @@ type Resource struct {
 // displayed by Terraform plan output.
 //
 // Examples:
-//   `+ aws_route53_record.record`
-//   `-/+ module.module_name (new resource required)`
+//
+//	`+ aws_route53_record.record`
+//	`-/+ module.module_name (new resource required)`
 type Header struct {
 	Change      *string `parser:"@(\"-\" \"/\" \"+\" | \"<\" \"=\" | \"+\" | \"-\" | \"~\")"`
 	Name        *string `parser:"@(Ident { (\".\" | \"-\") (Ident | Int)+ | \"[\" Int \"]\" })"`
 	Taint       bool    `parser:"{ @(\"(\" \"tainted\" \")\") }"`
 	NewResource bool    `parser:"{ @(\"(\" \"new\" \"resource\" \"required\" \")\") }"`
 	_           *string `parser:"\"\\n\""`
+
+	// MovedFrom is the previous address of moved resources.
+	MovedFrom string
+
+	// ImportedFrom is the ID of resources imported along with another
+	// change, e.g. updated in-place.
+	ImportedFrom string
 }
 
 // The Attribute struct is responsible for parsing the attributes of each
 // resource displayed by Terraform plan output.
 //
 // Examples:
-//   `id:              <computed>`
-//   `policy_arn:      "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"``
-//   `allow_overwrite: "" => "true"`
+//
+//	`id:              <computed>`
+//	`policy_arn:      "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"``
+//	`allow_overwrite: "" => "true"`
+//	`password:        <sensitive> => <sensitive> (attribute changed)`
 type Attribute struct {
-	Key           *string `parser:"@(Ident { \".\" | \"#\" | \"%\" | \"*\" | \"~\" | \"/\" | \"-\" | Ident | Float }) \":\""`
-	Before        *string `parser:"((@String \"=\" \">\""`
-	After         *string `parser:"  (@String"`
-	AfterComputed *string `parser:" 	| @(\"<\" Ident \">\")))"`
-	Value         *string `parser:" | @String"`
-	Computed      *string `parser:" | @(\"<\" Ident \">\"))"`
-	NewResource   bool    `parser:"{ @(\"(\" \"forces\" \"new\" \"resource\" \")\") }"`
-	_             *string `parser:"\"\\n\""`
+	Key              *string `parser:"@(Ident { \".\" | \"#\" | \"%\" | \"*\" | \"~\" | \"/\" | \"-\" | Ident | Float }) \":\""`
+	Before           *string `parser:"((@(String | \"<\" Ident \">\") \"=\" \">\""`
+	After            *string `parser:"  (@String"`
+	AfterComputed    *string `parser:" 	| @(\"<\" Ident \">\")))"`
+	Value            *string `parser:" | @String"`
+	Computed         *string `parser:" | @(\"<\" Ident \">\"))"`
+	AttributeChanged bool    `parser:"{ @(\"(\" \"attribute\" \"changed\" \")\") }"`
+	NewResource      bool    `parser:"{ @(\"(\" \"forces\" \"new\" \"resource\" \")\") }"`
+	_                *string `parser:"\"\\n\""`
 }
 
 const noChanges = "NO_CHANGES_STRING"
@@ -80,12 +112,71 @@ const noChanges = "NO_CHANGES_STRING"
 // to be parsed.
 var ErrParseFailure = errors.New("parse failure error")
 
+// Parser parses Terraform plan outputs. Building the grammar is expensive so
+// a Parser should be built once and reused. A Parser is safe for concurrent
+// use.
+type Parser struct {
+	grammar *participle.Parser
+}
+
+// NewParser builds the grammar used to parse Terraform plan outputs.
+func NewParser() (*Parser, error) {
+	grammar, err := participle.Build(
+		&Plan{},
+		participle.Lexer(&SceneryDefinition{}),
+		participle.UseLookahead(3),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{grammar: grammar}, nil
+}
+
+var (
+	defaultParser     *Parser
+	defaultParserErr  error
+	defaultParserOnce sync.Once
+)
+
 // Parse takes in an Terraform plan output string and returns a parsed
-// representation in the form of a Plan struct.
+// representation in the form of a Plan struct. The grammar is only built on
+// the first call.
 //
 // The ParseFailureErr error is returned the string is not able to be parsed
 // properly by the grammar.
 func Parse(inputPlan string) (*Plan, error) {
+	return ParseChunk(inputPlan, true, true)
+}
+
+// ParseChunk parses a chunk of a plan read by a ChunkReader with the default
+// parser (see Parser.ParseChunk).
+func ParseChunk(inputPlan string, first, last bool) (*Plan, error) {
+	defaultParserOnce.Do(func() {
+		defaultParser, defaultParserErr = NewParser()
+	})
+	if defaultParserErr != nil {
+		return &Plan{}, defaultParserErr
+	}
+
+	return defaultParser.ParseChunk(inputPlan, first, last)
+}
+
+// Parse takes in an Terraform plan output string and returns a parsed
+// representation in the form of a Plan struct.
+//
+// The ParseFailureErr error is returned the string is not able to be parsed
+// properly by the grammar.
+func (p *Parser) Parse(inputPlan string) (*Plan, error) {
+	return p.ParseChunk(inputPlan, true, true)
+}
+
+// ParseChunk parses a chunk of a plan read by a ChunkReader. The preface of the
+// plan (along with its changes made outside of Terraform and whether it has
+// no changes) is only looked for in the first chunk and its summary in the
+// last one, so that values of the resources in between are never mistaken
+// for them.
+func (p *Parser) ParseChunk(inputPlan string, first, last bool) (*Plan, error) {
 	defer func() {
 		// Parse will panic in the event of unrecognized character sequences or
 		// unsupported tokens. If we cannot parse the input it means it's not a
@@ -93,27 +184,33 @@ func Parse(inputPlan string) (*Plan, error) {
 		recover()
 	}()
 
-	p, err := participle.Build(
-		&Plan{},
-		participle.Lexer(&SceneryDefinition{}),
-		participle.UseLookahead(3),
-	)
-	if err != nil {
-		return &Plan{}, err
+	var drift []*Resource
+	if first {
+		var driftText string
+		driftText, inputPlan = splitDrift(inputPlan)
+		drift = parseDrift(driftText)
 	}
 
-	processedPlan, warnings := preprocessPlan(inputPlan)
+	inputPlan, actions := splitActions(inputPlan)
+
+	processedPlan, warnings := preprocessChunk(inputPlan, first, last)
 
 	if processedPlan == noChanges {
-		return &Plan{
-			NoChanges: true,
-			Warnings:  &warnings,
-		}, nil
+		if len(actions) == 0 {
+			return &Plan{
+				NoChanges: true,
+				Warnings:  &warnings,
+				Drift:     drift,
+			}, nil
+		}
+
+		// Moved resources may be listed although nothing else changes
+		processedPlan = ""
 	}
 
 	plan := &Plan{}
 
-	err = p.ParseString(processedPlan, plan)
+	err := p.grammar.ParseString(processedPlan, plan)
 	if err != nil {
 		return nil, ErrParseFailure
 	}
@@ -121,16 +218,68 @@ func Parse(inputPlan string) (*Plan, error) {
 	if warnings != nil {
 		plan.Warnings = &warnings
 	}
+	if first {
+		plan.Path = planPath(inputPlan)
+	}
+	if last {
+		plan.Destroy = destroyPromptRE.MatchString(inputPlan)
+	}
+	plan.Drift = drift
+
+	plan.Resources = append(plan.Resources, actions...)
+	if plan.Metadata != nil {
+		plan.Metadata.Move = plan.CountChanges().Move
+	}
 
 	return plan, nil
 }
 
+// The patterns used by preprocessPlan are compiled once as plans are parsed
+// a chunk at a time.
+var (
+	ansiRE = regexp.MustCompile("[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))")
+	initRE = regexp.MustCompile("- .*\\.\\.\\.")
+
+	separatorRE = regexp.MustCompile("--------+")
+	warningRE   = regexp.MustCompile("Warning:.*\n")
+	pathRE      = regexp.MustCompile("Path:([^\n]+)\n")
+	actionsRE   = regexp.MustCompile("Terraform will perform the following actions:.*\n")
+	noopPlanRE  = regexp.MustCompile("(No changes|This plan does nothing|This is a refresh-only plan)")
+	planRE      = regexp.MustCompile("Plan:[^\n]+")
+
+	// promptRE matches the confirmation prompts of `terraform apply` and
+	// `terraform destroy`, e.g. "Do you really want to destroy all resources?".
+	promptRE        = regexp.MustCompile(`Do you (?:really )?want to (?:perform these actions|destroy)[^\n]*\?`)
+	destroyPromptRE = regexp.MustCompile(`Do you really want to destroy[^\n]*\?`)
+)
+
+// planPath returns the path of the plan file from the last `Path:` line of
+// the plan, which preprocessPlan strips along with the rest of the preface.
+func planPath(planText string) string {
+	if !strings.Contains(planText, "Path:") {
+		return ""
+	}
+
+	matches := pathRE.FindAllStringSubmatch(ansiRE.ReplaceAllString(planText, ""), -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(matches[len(matches)-1][1])
+}
+
 func preprocessPlan(planText string) (string, []string) {
+	return preprocessChunk(planText, true, true)
+}
+
+// preprocessChunk strips what the grammar does not handle from a chunk of a
+// plan, only stripping the preface of the first chunk and the postface of the
+// last one.
+func preprocessChunk(planText string, first, last bool) (string, []string) {
 	var warnings []string
 	processedPlanText := planText
 
 	// Strip ANSI escape codes
-	ansiRE := regexp.MustCompile("[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))")
 	processedPlanText = ansiRE.ReplaceAllString(processedPlanText, "")
 
 	// Strip Terraform initialization messages. These preface messages
@@ -138,39 +287,44 @@ func preprocessPlan(planText string) (string, []string) {
 	//
 	// Example:
 	// 		"- Downloading plugin for provider "aws" (1.1.0)..."
-	initRE := regexp.MustCompile("- .*\\.\\.\\.")
 	processedPlanText = initRE.ReplaceAllString(processedPlanText, "")
 
 	// Strip Terraform section separators ("--------...")
-	separatorRE := regexp.MustCompile("--------+")
 	processedPlanText = separatorRE.ReplaceAllString(processedPlanText, "")
 
 	// Process Warnings
-	warningRE := regexp.MustCompile("Warning:.*\n")
 	if w := warningRE.FindStringSubmatch(processedPlanText); len(w) > 0 {
 		warnings = w
 		processedPlanText = warningRE.ReplaceAllString(processedPlanText, "")
 	}
 
-	// Strip preface
-	pathRE := regexp.MustCompile("Path:[^\n]+\n")
-	actionsRE := regexp.MustCompile("Terraform will perform the following actions:.*\n")
-	noopPlanRE := regexp.MustCompile("(No changes|This plan does nothing)")
-	switch {
-	case pathRE.MatchString(processedPlanText):
-		matches := pathRE.FindAllStringIndex(processedPlanText, -1)
-		lastMatchEndIndex := matches[len(matches)-1][1]
-		processedPlanText = processedPlanText[lastMatchEndIndex:]
-	case actionsRE.MatchString(processedPlanText):
-		matches := actionsRE.FindAllStringIndex(processedPlanText, -1)
-		lastMatchEndIndex := matches[len(matches)-1][1]
-		processedPlanText = processedPlanText[lastMatchEndIndex:]
-	case noopPlanRE.MatchString(processedPlanText):
-		return noChanges, warnings
+	// Strip the preface, only found in the first chunk
+	if first {
+		switch {
+		case pathRE.MatchString(processedPlanText):
+			matches := pathRE.FindAllStringIndex(processedPlanText, -1)
+			lastMatchEndIndex := matches[len(matches)-1][1]
+			processedPlanText = processedPlanText[lastMatchEndIndex:]
+		case actionsRE.MatchString(processedPlanText):
+			matches := actionsRE.FindAllStringIndex(processedPlanText, -1)
+			lastMatchEndIndex := matches[len(matches)-1][1]
+			processedPlanText = processedPlanText[lastMatchEndIndex:]
+		case noopPlanRE.MatchString(processedPlanText):
+			return noChanges, warnings
+		}
+	}
+
+	// The summary and whatever follows it are only found in the last chunk
+	if !last {
+		return processedPlanText, warnings
+	}
+
+	// Strip confirmation prompts along with whatever follows them
+	if loc := promptRE.FindStringIndex(processedPlanText); loc != nil {
+		processedPlanText = processedPlanText[:loc[0]]
 	}
 
 	// Strip postface
-	planRE := regexp.MustCompile("Plan:[^\n]+")
 	if planRE.MatchString(processedPlanText) {
 		matches := planRE.FindAllStringIndex(processedPlanText, -1)
 		lastMatchEndIndex := matches[len(matches)-1][1]
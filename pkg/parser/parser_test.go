@@ -1,8 +1,13 @@
 package parser
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -285,6 +290,44 @@ func TestParse(t *testing.T) {
 		assert.Equal(tt, expected, plan)
 	})
 
+	t.Run("parses changed sensitive attributes", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/processedPlans/sensitive.txt")
+		assert.NoError(tt, err)
+
+		expected := &Plan{
+			Resources: []*Resource{
+				{
+					Header: &Header{
+						Change: String("~"),
+						Name:   String("aws_db_instance.main"),
+					},
+					Attributes: []*Attribute{
+						{
+							Key:      String("id"),
+							Computed: String("<computed>"),
+						},
+						{
+							Key:              String("password"),
+							Before:           String("<sensitive>"),
+							AfterComputed:    String("<sensitive>"),
+							AttributeChanged: true,
+						},
+						{
+							Key:    String("username"),
+							Before: String("admin"),
+							After:  String("root"),
+						},
+					},
+				},
+			},
+		}
+
+		plan, err := Parse(string(input))
+		assert.NoError(t, err)
+
+		assert.Equal(tt, expected, plan)
+	})
+
 	t.Run("parses plan with no newline between resources and metadata", func(tt *testing.T) {
 		input, err := ioutil.ReadFile("../../fixtures/processedPlans/resourceMetadataNoNewLine.txt")
 		assert.NoError(tt, err)
@@ -320,8 +363,571 @@ func TestParse(t *testing.T) {
 
 		assert.Equal(tt, expected, plan)
 	})
+
+	t.Run("records the path of saved plans", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/preface2Input.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, "terraform.tfplan", plan.Path)
+	})
+
+	t.Run("recognises destroy runs", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/destroyInput.txt")
+		assert.NoError(tt, err)
+
+		// The confirmation prompt is stripped even without a summary
+		for _, input := range []string{string(input), strings.Replace(string(input), "Plan: 0 to add, 0 to change, 5 to destroy.", "", -1)} {
+			plan, err := Parse(input)
+			assert.NoError(tt, err)
+
+			assert.Len(tt, plan.Resources, 5)
+			assert.True(tt, plan.Destroy)
+		}
+	})
+
+	t.Run("parses changes made outside of Terraform", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/driftInput.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+
+		assert.Len(tt, plan.Resources, 2)
+		assert.Len(tt, plan.Drift, 2)
+		assert.Equal(tt, DriftSummary{Changed: 1, Deleted: 1}, plan.CountDrift())
+
+		changed := plan.Drift[0]
+		assert.Equal(tt, "~", *changed.Header.Change)
+		assert.Equal(tt, "aws_instance.web", *changed.Header.Name)
+
+		attributes := map[string]*Attribute{}
+		for _, a := range changed.Attributes {
+			attributes[*a.Key] = a
+		}
+		assert.Equal(tt, "t2.micro", *attributes["instance_type"].Before)
+		assert.Equal(tt, "t2.small", *attributes["instance_type"].After)
+		assert.Equal(tt, "20", *attributes["root_block_device.0.volume_size"].After)
+
+		assert.Equal(tt, "-", *plan.Drift[1].Header.Change)
+		assert.Equal(tt, "aws_s3_bucket.old", *plan.Drift[1].Header.Name)
+
+		assert.Equal(tt, "~", *plan.Resources[0].Header.Change)
+		assert.Equal(tt, "aws_instance.web", *plan.Resources[0].Header.Name)
+		assert.Equal(tt, "+", *plan.Resources[1].Header.Change)
+		assert.Equal(tt, "aws_s3_bucket.old", *plan.Resources[1].Header.Name)
+	})
+
+	t.Run("parses the plans of Terraform 0.12 and later", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/modernInput.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, &Metadata{Add: 2, Change: 1, Destroy: 2}, plan.Metadata)
+
+		var changes []string
+		for _, r := range plan.Resources {
+			changes = append(changes, *r.Header.Change)
+		}
+		assert.Equal(tt, []string{"<=", "+", "-", "-/+", "~"}, changes)
+
+		attributes := func(r *Resource) map[string]*Attribute {
+			m := map[string]*Attribute{}
+			for _, a := range r.Attributes {
+				m[*a.Key] = a
+			}
+			return m
+		}
+
+		created := attributes(plan.Resources[1])
+		assert.Equal(tt, "<computed>", *created["id"].Computed)
+		assert.Equal(tt, "vpc", *created["domain"].Value)
+		assert.Equal(tt, "web", *created["tags.Name"].Value)
+
+		replaced := plan.Resources[3]
+		assert.True(tt, replaced.Header.NewResource)
+		assert.True(tt, attributes(replaced)["ami"].NewResource)
+		assert.Equal(tt, "<computed>", *attributes(replaced)["id"].AfterComputed)
+
+		userData := attributes(replaced)["user_data"]
+		assert.Equal(tt, "#!/bin/bash\necho \"hello\" > /var/www/index.html\nsystemctl restart nginx\n", *userData.Before)
+		assert.Equal(tt, "#!/bin/bash\necho \"hello, world\" > /var/www/index.html\nsystemctl restart nginx\n", *userData.After)
+
+		updated := attributes(plan.Resources[4])
+		assert.Equal(tt, "0.0.0.0/0", *updated["ingress.0.cidr_blocks.0"].Before)
+		assert.Equal(tt, "10.0.0.0/8", *updated["ingress.1.cidr_blocks.0"].After)
+	})
+
+	t.Run("parses jsonencode values of Terraform 0.12 and later", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/modernIAMPolicyInput.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+		assert.Len(tt, plan.Resources, 1)
+
+		attributes := map[string]*Attribute{}
+		for _, a := range plan.Resources[0].Attributes {
+			attributes[*a.Key] = a
+		}
+
+		// The value replaced by a list is kept as removed
+		assert.Equal(tt, "s3:GetObject", *attributes["policy.Statement.0.Action"].Before)
+		assert.Equal(tt, "", *attributes["policy.Statement.0.Action"].After)
+		assert.Equal(tt, "s3:PutObject", *attributes["policy.Statement.0.Action.1"].After)
+		assert.Equal(tt, "Allow", *attributes["policy.Statement.0.Effect"].Value)
+		assert.Equal(tt, "arn:aws:s3:::artifacts/*", *attributes["policy.Statement.0.Resource.0"].Before)
+		assert.Equal(tt, "sqs:*", *attributes["policy.Statement.1.Action"].After)
+		assert.Equal(tt, "{}", *attributes["tags"].Value)
+
+		// Lists replaced by a value
+		plan, err = Parse("Terraform will perform the following actions:\n\n  # aws_iam_policy.deploy will be updated in-place\n  ~ resource \"aws_iam_policy\" \"deploy\" {\n      ~ policy = jsonencode(\n          ~ {\n              ~ Action = [\n                  - \"s3:GetObject\",\n                ] -> \"s3:*\"\n            }\n        )\n    }\n\nPlan: 0 to add, 1 to change, 0 to destroy.\n")
+		assert.NoError(tt, err)
+
+		var keys []string
+		for _, a := range plan.Resources[0].Attributes {
+			keys = append(keys, *a.Key)
+		}
+		assert.Equal(tt, []string{"policy.Action.0", "policy.Action"}, keys)
+		assert.Equal(tt, "s3:*", *plan.Resources[0].Attributes[1].After)
+	})
+
+	t.Run("parses tainted resources of Terraform 0.12 and later", func(tt *testing.T) {
+		plan, err := Parse("Terraform will perform the following actions:\n\n  # aws_instance.web is tainted, so must be replaced\n-/+ resource \"aws_instance\" \"web\" {\n      ~ id = \"i-0123456789abcdef0\" -> (known after apply)\n    }\n\nPlan: 1 to add, 0 to change, 1 to destroy.\n")
+		assert.NoError(tt, err)
+
+		assert.Len(tt, plan.Resources, 1)
+		assert.True(tt, plan.Resources[0].Header.Taint)
+		assert.NoError(tt, plan.CheckSummary())
+	})
+
+	t.Run("parses moved, imported and forgotten resources", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/movedImportedForgottenInput.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, &Metadata{Import: 2, Add: 1, Change: 2, Forget: 1, Move: 2}, plan.Metadata)
+		assert.Len(tt, plan.Resources, 6)
+
+		created, forgotten, moved := plan.Resources[0], plan.Resources[1], plan.Resources[2]
+		movedUpdated, importedUpdated, imported := plan.Resources[3], plan.Resources[4], plan.Resources[5]
+
+		assert.Equal(tt, "+", *created.Header.Change)
+
+		assert.Equal(tt, ForgetChange, *forgotten.Header.Change)
+		assert.Equal(tt, "aws_iam_role.legacy", *forgotten.Header.Name)
+
+		assert.Equal(tt, MoveChange, *moved.Header.Change)
+		assert.Equal(tt, "aws_instance.api", *moved.Header.Name)
+		assert.Equal(tt, "aws_instance.web", moved.Header.MovedFrom)
+		assert.Len(tt, moved.Attributes, 2)
+
+		assert.Equal(tt, "~", *movedUpdated.Header.Change)
+		assert.Equal(tt, "aws_instance.worker", *movedUpdated.Header.Name)
+		assert.Equal(tt, "aws_instance.jobs", movedUpdated.Header.MovedFrom)
+
+		assert.Equal(tt, "~", *importedUpdated.Header.Change)
+		assert.Equal(tt, "acme-assets", importedUpdated.Header.ImportedFrom)
+
+		assert.Equal(tt, ImportChange, *imported.Header.Change)
+		assert.Equal(tt, "versioning.0.enabled", *imported.Attributes[5].Key)
+		assert.Equal(tt, "true", *imported.Attributes[5].Value)
+	})
+
+	t.Run("parses summaries with imported and forgotten resources", func(tt *testing.T) {
+		plan, err := Parse("  ~ aws_eip.web\n\nPlan: 2 to import, 0 to add, 1 to change, 0 to destroy, 3 to forget.\n")
+		assert.NoError(tt, err)
+		assert.Equal(tt, &Metadata{Import: 2, Change: 1, Forget: 3}, plan.Metadata)
+		assert.Error(tt, plan.CheckSummary())
+	})
+
+	t.Run("parses refresh-only plans", func(tt *testing.T) {
+		input, err := ioutil.ReadFile("../../fixtures/rawPlans/driftRefreshOnlyInput.txt")
+		assert.NoError(tt, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(tt, err)
+
+		assert.True(tt, plan.NoChanges)
+		assert.Equal(tt, DriftSummary{Changed: 1}, plan.CountDrift())
+	})
 }
 
 func String(v string) *string {
 	return &v
 }
+
+func TestCheckSummary(t *testing.T) {
+	cases := []struct {
+		inputFile     string
+		expected      Metadata
+		expectedError bool
+	}{
+		{"../../fixtures/rawPlans/accessibleInput.txt", Metadata{Add: 2, Change: 1, Destroy: 2}, false},
+		{"../../fixtures/rawPlans/collapseInput.txt", Metadata{Add: 2, Change: 1}, false},
+		{"../../fixtures/rawPlans/base64Input.txt", Metadata{Change: 1}, false},
+		{"../../fixtures/rawPlans/summaryMismatchInput.txt", Metadata{Add: 2, Destroy: 1}, true},
+		{"../../fixtures/rawPlans/movedImportedForgottenInput.txt", Metadata{Import: 2, Add: 1, Change: 2, Forget: 1, Move: 2}, false},
+		{"../../fixtures/rawPlans/driftInput.txt", Metadata{Add: 1, Change: 1}, false},
+		{"../../fixtures/rawPlans/modernInput.txt", Metadata{Add: 2, Change: 1, Destroy: 2}, false},
+	}
+
+	for _, tc := range cases {
+		input, err := ioutil.ReadFile(tc.inputFile)
+		assert.NoError(t, err)
+
+		plan, err := Parse(string(input))
+		assert.NoError(t, err)
+
+		assert.Equal(t, tc.expected, plan.CountChanges(), tc.inputFile)
+
+		if tc.expectedError {
+			assert.Error(t, plan.CheckSummary(), tc.inputFile)
+		} else {
+			assert.NoError(t, plan.CheckSummary(), tc.inputFile)
+		}
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	longValue := strings.Repeat("a", 100000)
+
+	cases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			"splits resources",
+			"Terraform will perform the following actions:\n\n  + aws_eip.web\n      id: <computed>\n\n  ~ aws_security_group.web\n      name: \"a\" => \"b\"\n\n\nPlan: 1 to add, 1 to change, 0 to destroy.\n",
+			[]string{
+				"Terraform will perform the following actions:\n\n  + aws_eip.web\n      id: <computed>\n\n",
+				"  ~ aws_security_group.web\n      name: \"a\" => \"b\"\n\n\nPlan: 1 to add, 1 to change, 0 to destroy.\n",
+			},
+		},
+		{
+			"ignores the legend",
+			"Resource actions are indicated with the following symbols:\n  + create\n  - destroy\n <= read (data resources)\n\nTerraform will perform the following actions:\n\n-/+ aws_instance.web (new resource required)\n\n <= data.aws_ami.ubuntu\n",
+			[]string{
+				"Resource actions are indicated with the following symbols:\n  + create\n  - destroy\n <= read (data resources)\n\nTerraform will perform the following actions:\n\n-/+ aws_instance.web (new resource required)\n\n",
+				" <= data.aws_ami.ubuntu\n",
+			},
+		},
+		{
+			"splits plans without preface",
+			"  + aws_eip.a\n  + aws_eip.b",
+			[]string{"  + aws_eip.a\n", "  + aws_eip.b"},
+		},
+		{
+			"reads long lines",
+			"  ~ aws_iam_policy.a\n      policy: \"" + longValue + "\" => \"\"\n",
+			[]string{"  ~ aws_iam_policy.a\n      policy: \"" + longValue + "\" => \"\"\n"},
+		},
+		{
+			"splits moved, imported and forgotten resources",
+			"Terraform will perform the following actions:\n\n  # aws_eip.a has moved to aws_eip.b\n    resource \"aws_eip\" \"b\" {\n        id = \"eipalloc-1\"\n    }\n\n  # aws_eip.c will be imported\n    resource \"aws_eip\" \"c\" {\n        id = \"eipalloc-2\"\n    }\n",
+			[]string{
+				"Terraform will perform the following actions:\n\n  # aws_eip.a has moved to aws_eip.b\n    resource \"aws_eip\" \"b\" {\n        id = \"eipalloc-1\"\n    }\n\n",
+				"  # aws_eip.c will be imported\n    resource \"aws_eip\" \"c\" {\n        id = \"eipalloc-2\"\n    }\n",
+			},
+		},
+		{
+			"does not split the lists of Terraform 0.12 and later",
+			"Terraform will perform the following actions:\n\n  # aws_security_group.a will be updated in-place\n  ~ resource \"aws_security_group\" \"a\" {\n      ~ cidr_blocks = [\n          - \"10.0.0.0/8\",\n          + \"10.0.0.0/16\",\n        ]\n    }\n\n  # aws_eip.b will be created\n  + resource \"aws_eip\" \"b\" {\n      + id = (known after apply)\n    }\n",
+			[]string{
+				"Terraform will perform the following actions:\n\n  # aws_security_group.a will be updated in-place\n  ~ resource \"aws_security_group\" \"a\" {\n      ~ cidr_blocks = [\n          - \"10.0.0.0/8\",\n          + \"10.0.0.0/16\",\n        ]\n    }\n\n",
+				"  # aws_eip.b will be created\n  + resource \"aws_eip\" \"b\" {\n      + id = (known after apply)\n    }\n",
+			},
+		},
+		{
+			"keeps plans without changes whole",
+			"Refreshing Terraform state in-memory prior to plan...\n\nNo changes. Infrastructure is up-to-date.\n",
+			[]string{"Refreshing Terraform state in-memory prior to plan...\n\nNo changes. Infrastructure is up-to-date.\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks := NewChunkReader(strings.NewReader(tc.input))
+
+			var actual []string
+			for {
+				chunk, err := chunks.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+
+				actual = append(actual, chunk)
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestChunkReaderPosition(t *testing.T) {
+	chunks := NewChunkReader(strings.NewReader("  + aws_eip.a\n  + aws_eip.b\n  + aws_eip.c\n"))
+
+	var first, last []bool
+	for {
+		_, err := chunks.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		first = append(first, chunks.First())
+		last = append(last, chunks.Last())
+	}
+
+	assert.Equal(t, []bool{true, false, false}, first)
+	assert.Equal(t, []bool{false, false, true}, last)
+}
+
+func TestParserConcurrentUse(t *testing.T) {
+	p, err := NewParser()
+	assert.NoError(t, err)
+
+	input := generatePlan(20)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			plan, err := p.Parse(input)
+			assert.NoError(t, err)
+			assert.Len(t, plan.Resources, 20)
+		}()
+	}
+	wg.Wait()
+}
+
+// generatePlan returns a plan creating the given number of resources.
+func generatePlan(resources int) string {
+	var b strings.Builder
+
+	b.WriteString("Terraform will perform the following actions:\n\n")
+	for i := 0; i < resources; i++ {
+		fmt.Fprintf(&b, "  + aws_instance.web_%d\n", i)
+		b.WriteString("      id:                <computed>\n")
+		b.WriteString("      ami:               \"ami-2757f631\"\n")
+		b.WriteString("      instance_type:     \"t2.micro\"\n")
+		b.WriteString("      tags.%:            \"1\"\n")
+		fmt.Fprintf(&b, "      tags.Name:         \"web-%d\"\n\n", i)
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to add, 0 to change, 0 to destroy.\n", resources)
+
+	return b.String()
+}
+
+// BenchmarkParse parses a large plan a resource at a time, the way plans are
+// streamed, with the default parser.
+func BenchmarkParse(b *testing.B) {
+	chunks := generateChunks(b, 500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, chunk := range chunks {
+			if _, err := ParseChunk(chunk, j == 0, j == len(chunks)-1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkParseRebuildingGrammar parses the same plan as BenchmarkParse,
+// building the grammar for every chunk as was done before Parser existed.
+func BenchmarkParseRebuildingGrammar(b *testing.B) {
+	chunks := generateChunks(b, 500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, chunk := range chunks {
+			p, err := NewParser()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := p.Parse(chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkParseWhole parses a large plan in one go.
+func BenchmarkParseWhole(b *testing.B) {
+	input := generatePlan(500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func generateChunks(b *testing.B, resources int) []string {
+	reader := NewChunkReader(strings.NewReader(generatePlan(resources)))
+
+	var chunks []string
+	for {
+		chunk, err := reader.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestSplitModules(t *testing.T) {
+	input, err := ioutil.ReadFile("../../fixtures/rawPlans/terragruntInput.txt")
+	assert.NoError(t, err)
+
+	modules := SplitModules(string(input))
+
+	assert.Equal(t, []ModuleOutput{
+		{
+			Module: "envs/prod/vpc",
+			Output: "Refreshing Terraform state in-memory prior to plan...\n\nAn execution plan has been generated and is shown below.\nResource actions are indicated with the following symbols:\n  + create\n\nTerraform will perform the following actions:\n\n  + aws_vpc.main\n      id:         <computed>\n      cidr_block: \"10.0.0.0/16\"\n\n\nPlan: 1 to add, 0 to change, 0 to destroy.\n",
+		},
+		{
+			Module: "envs/prod/app",
+			Output: "Refreshing Terraform state in-memory prior to plan...\nNo changes. Infrastructure is up-to-date.\n",
+		},
+		{
+			Module: "envs/prod/db",
+			Output: "Terraform will perform the following actions:\n\n  ~ aws_db_instance.main\n      allocated_storage: \"20\" => \"50\"\n\n\nPlan: 0 to add, 1 to change, 0 to destroy.\n",
+		},
+	}, modules)
+
+	// Unprefixed lines are kept with the module of the lines around them
+	assert.Equal(t, []ModuleOutput{
+		{Module: "envs/prod/vpc", Output: "starting\n  + aws_vpc.main\n      tags.%: \"1\"\n"},
+		{Module: "envs/prod/db", Output: "  ~ aws_db_instance.main\n"},
+	}, SplitModules("starting\n[envs/prod/vpc]   + aws_vpc.main\n[terragrunt] 2019/03/04 10:12:01 Module done\n      tags.%: \"1\"\n[envs/prod/db]   ~ aws_db_instance.main\n"))
+
+	assert.Equal(t, []ModuleOutput{
+		{Output: "Terraform will perform the following actions:\n\n  + aws_eip.web\n"},
+	}, SplitModules("Terraform will perform the following actions:\n\n  + aws_eip.web\n"))
+}
+
+func TestParseApply(t *testing.T) {
+	t.Run("records timings and the summary", func(tt *testing.T) {
+		input := "aws_eip.web: Creating...\n" +
+			"aws_eip.web: Still creating... [10s elapsed]\n" +
+			"aws_eip.web: Creation complete after 1m2s [id=eipalloc-2e0f8f15]\n" +
+			"aws_instance.web: Destroying... [id=i-0123456789abcdef0]\n" +
+			"aws_instance.web: Still destroying... [id=i-0123456789abcdef0, 10s elapsed]\n" +
+			"\n" +
+			"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.\n"
+
+		apply, err := ParseApply(input)
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, &Apply{
+			Resources: []*AppliedResource{
+				{Address: "aws_eip.web", Change: "+", ID: "eipalloc-2e0f8f15", Elapsed: 62 * time.Second, Complete: true},
+				{Address: "aws_instance.web", Change: "-", ID: "i-0123456789abcdef0", Elapsed: 10 * time.Second},
+			},
+			Summary: &ApplySummary{Verb: "Apply", Added: 1},
+		}, apply)
+	})
+
+	t.Run("records the summary of destroys", func(tt *testing.T) {
+		input := "aws_eip.web: Destroying... [id=eipalloc-2e0f8f15]\n" +
+			"aws_eip.web: Destruction complete after 1s\n" +
+			"\n" +
+			"Destroy complete! Resources: 1 destroyed.\n"
+
+		apply, err := ParseApply(input)
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, &ApplySummary{Verb: "Destroy", Destroyed: 1}, apply.Summary)
+	})
+
+	t.Run("records replacements", func(tt *testing.T) {
+		input := "aws_instance.web: Destroying... (ID: i-0123456789abcdef0)\n" +
+			"aws_instance.web: Destruction complete after 31s\n" +
+			"aws_instance.web: Creating...\n" +
+			"aws_instance.web: Still creating... (10s elapsed)\n"
+
+		apply, err := ParseApply(input)
+		assert.NoError(tt, err)
+
+		assert.Equal(tt, "-/+", apply.Resources[0].Change)
+		assert.Equal(tt, 41*time.Second, apply.Resources[0].Elapsed)
+		assert.False(tt, apply.Resources[0].Complete)
+	})
+
+	t.Run("fails on other outputs", func(tt *testing.T) {
+		_, err := ParseApply("Terraform will perform the following actions:\n\n  + aws_eip.web\n")
+		assert.Equal(tt, ErrParseFailure, err)
+	})
+}
+
+func TestParseState(t *testing.T) {
+	input := "# module.app.aws_security_group.web:\n" +
+		"resource \"aws_security_group\" \"web\" {\n" +
+		"    description = \"Web \\\"servers\\\"\"\n" +
+		"    ingress     = [\n" +
+		"        {\n" +
+		"            cidr_blocks = [\n" +
+		"                \"0.0.0.0/0\",\n" +
+		"            ]\n" +
+		"            from_port   = 443\n" +
+		"            self        = false\n" +
+		"        },\n" +
+		"    ]\n" +
+		"    name_prefix = null\n" +
+		"    tags        = {\n" +
+		"        \"Name\" = \"web\"\n" +
+		"    }\n" +
+		"\n" +
+		"    timeouts {\n" +
+		"        create = \"5m\"\n" +
+		"    }\n" +
+		"}\n"
+
+	state, err := ParseState(input)
+	assert.NoError(t, err)
+
+	attribute := func(key, value string) *Attribute {
+		return &Attribute{Key: String(key), Value: String(value)}
+	}
+
+	assert.Equal(t, &State{
+		Resources: []*StateResource{
+			{
+				Address: "module.app.aws_security_group.web",
+				Attributes: []*Attribute{
+					attribute("description", "Web \"servers\""),
+					attribute("ingress.#", "1"),
+					attribute("ingress.0.cidr_blocks.#", "1"),
+					attribute("ingress.0.cidr_blocks.0", "0.0.0.0/0"),
+					attribute("ingress.0.from_port", "443"),
+					attribute("ingress.0.self", "false"),
+					attribute("tags.%", "1"),
+					attribute("tags.Name", "web"),
+					attribute("timeouts.#", "1"),
+					attribute("timeouts.0.create", "5m"),
+				},
+			},
+		},
+	}, state)
+
+	_, err = ParseState("Terraform will perform the following actions:\n")
+	assert.Equal(t, ErrParseFailure, err)
+}
@@ -0,0 +1,378 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// State is the parsed output of `terraform state show` or `terraform show`
+// (Terraform 0.12 and later).
+type State struct {
+	Resources []*StateResource
+}
+
+// StateResource is a resource of the state. Its attributes only have a Value
+// (or a Computed value for sensitive ones), nested values being flattened to
+// dotted keys the way Terraform 0.11 displays them.
+//
+// Example:
+//
+//	`tags = { "Name" = "web" }` => `tags.% = "1"`, `tags.Name = "web"`
+type StateResource struct {
+	Address    string
+	Attributes []*Attribute
+}
+
+var (
+	// stateHeaderRE matches the line introducing each resource, e.g.
+	// `# aws_instance.web:`.
+	stateHeaderRE = regexp.MustCompile(`^# (\S+):$`)
+
+	// stateBlockRE matches the opening of a resource or of a nested block,
+	// e.g. `resource "aws_instance" "web" {` or `root_block_device {`.
+	stateBlockRE = regexp.MustCompile(`^([a-zA-Z0-9_-]+)(?: "[^"]*")* \{$`)
+
+	stateHeredocRE = regexp.MustCompile(`^<<(-?)([A-Za-z_]+)$`)
+)
+
+// stateSensitive is how sensitive values are displayed in the state.
+const stateSensitive = "(sensitive value)"
+
+// IsStateHeader reports whether the line introduces a resource of the output
+// of `terraform state show` or `terraform show`.
+func IsStateHeader(line string) bool {
+	return stateHeaderRE.MatchString(ansiRE.ReplaceAllString(strings.TrimRight(line, "\r\n"), ""))
+}
+
+// ParseState takes in the output of `terraform state show` or `terraform
+// show` and returns its resources. Anything else, such as outputs, is
+// ignored.
+//
+// The ErrParseFailure error is returned if the output holds no resource.
+func ParseState(input string) (*State, error) {
+	p := &stateParser{lines: strings.Split(ansiRE.ReplaceAllString(input, ""), "\n")}
+	state := &State{}
+
+	for p.pos < len(p.lines) {
+		m := stateHeaderRE.FindStringSubmatch(strings.TrimSpace(p.next()))
+		if m == nil {
+			continue
+		}
+
+		r := &StateResource{Address: m[1]}
+		state.Resources = append(state.Resources, r)
+
+		// The resource itself, e.g. `resource "aws_instance" "web" {`
+		for p.pos < len(p.lines) {
+			line := strings.TrimSpace(p.next())
+			if line == "" {
+				continue
+			}
+
+			if stateBlockRE.MatchString(line) {
+				r.Attributes = flattenState("", p.parseObject())
+			}
+			break
+		}
+	}
+
+	if len(state.Resources) == 0 {
+		return nil, ErrParseFailure
+	}
+
+	return state, nil
+}
+
+// stateParser parses the HCL-like values of the state a line at a time.
+type stateParser struct {
+	lines []string
+	pos   int
+}
+
+// stateEntry is an attribute or a nested block of an object.
+type stateEntry struct {
+	key   string
+	value interface{}
+	block bool
+}
+
+// stateObject keeps the entries of an object in order.
+type stateObject []stateEntry
+
+// stateJSON is a value encoded with `jsonencode(...)`.
+type stateJSON struct {
+	value interface{}
+}
+
+// stateScalar is a number, a boolean or a sensitive value, as written.
+type stateScalar string
+
+func (p *stateParser) next() string {
+	line := strings.TrimRight(p.lines[p.pos], "\r")
+	p.pos++
+	return line
+}
+
+// parseObject parses the entries of an object or of a block up to its closing
+// brace.
+func (p *stateParser) parseObject() stateObject {
+	var o stateObject
+
+	for p.pos < len(p.lines) {
+		line := strings.TrimSpace(p.next())
+
+		switch {
+		case line == "":
+			continue
+		case line == "}" || line == "},":
+			return o
+		case stateBlockRE.MatchString(line):
+			name := line[:strings.IndexAny(line, " ")]
+			o = append(o, stateEntry{key: name, value: p.parseObject(), block: true})
+			continue
+		}
+
+		key, rest := splitStateEntry(line)
+		o = append(o, stateEntry{key: key, value: p.parseValue(rest)})
+	}
+
+	return o
+}
+
+// parseList parses the elements of a list up to its closing bracket.
+func (p *stateParser) parseList() []interface{} {
+	var l []interface{}
+
+	for p.pos < len(p.lines) {
+		line := strings.TrimSpace(p.next())
+
+		switch line {
+		case "":
+			continue
+		case "]", "],":
+			return l
+		}
+
+		l = append(l, p.parseValue(line))
+	}
+
+	return l
+}
+
+// parseValue parses the value starting with the given text, reading the
+// following lines for multi-line values.
+func (p *stateParser) parseValue(text string) interface{} {
+	text = strings.TrimSuffix(strings.TrimSpace(text), ",")
+
+	switch {
+	case text == "{":
+		return p.parseObject()
+	case text == "[":
+		return p.parseList()
+	case text == "{}":
+		return stateObject{}
+	case text == "[]":
+		return []interface{}{}
+	case text == "jsonencode(":
+		var v interface{}
+		for p.pos < len(p.lines) {
+			line := strings.TrimSpace(p.next())
+			if line == "" {
+				continue
+			}
+			if line != ")" && line != ")," {
+				v = p.parseValue(line)
+				continue
+			}
+			break
+		}
+		return stateJSON{v}
+	case text == "null":
+		return nil
+	case strings.HasPrefix(text, `"`):
+		return unquoteState(text)
+	}
+
+	if m := stateHeredocRE.FindStringSubmatch(text); m != nil {
+		return p.parseHeredoc(m[2], m[1] == "-")
+	}
+
+	return stateScalar(text)
+}
+
+// parseHeredoc reads the lines of a heredoc string up to its delimiter,
+// removing the indentation of `<<-` heredocs.
+func (p *stateParser) parseHeredoc(delimiter string, indented bool) string {
+	var lines []string
+
+	for p.pos < len(p.lines) {
+		line := p.next()
+		if strings.TrimSpace(line) == delimiter {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	if indented {
+		indent := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			if n := len(l) - len(strings.TrimLeft(l, " \t")); indent < 0 || n < indent {
+				indent = n
+			}
+		}
+
+		for i, l := range lines {
+			if len(l) >= indent && indent > 0 {
+				lines[i] = l[indent:]
+			}
+		}
+	}
+
+	return unescapeTemplate(strings.Join(lines, "\n") + "\n")
+}
+
+// splitStateEntry splits a `key = value` line, the key being quoted in maps.
+func splitStateEntry(line string) (string, string) {
+	if strings.HasPrefix(line, `"`) {
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+				continue
+			}
+			if line[i] == '"' {
+				return unquoteState(line[:i+1]), strings.TrimPrefix(strings.TrimSpace(line[i+1:]), "= ")
+			}
+		}
+	}
+
+	i := strings.Index(line, " = ")
+	if i < 0 {
+		return strings.TrimSpace(line), ""
+	}
+
+	return strings.TrimSpace(line[:i]), line[i+3:]
+}
+
+// unquoteState unquotes an HCL string, whose escapes are those of Go strings
+// along with the escapes of template sequences.
+func unquoteState(s string) string {
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		unquoted = strings.Trim(s, `"`)
+	}
+
+	return unescapeTemplate(unquoted)
+}
+
+// unescapeTemplate replaces the escapes of literal template sequences of HCL
+// strings and heredocs.
+func unescapeTemplate(s string) string {
+	return strings.NewReplacer("$${", "${", "%%{", "%{").Replace(s)
+}
+
+// flattenState returns the attributes of an object, nested values being
+// flattened to dotted keys. Nested blocks are numbered and counted like lists.
+func flattenState(prefix string, o stateObject) []*Attribute {
+	var attributes []*Attribute
+
+	counts := map[string]int{}
+	for _, e := range o {
+		if e.block {
+			counts[e.key]++
+		}
+	}
+
+	blocks := map[string]int{}
+	for _, e := range o {
+		key := prefix + e.key
+		if !e.block {
+			attributes = append(attributes, flattenStateValue(key, e.value)...)
+			continue
+		}
+
+		if blocks[e.key] == 0 {
+			attributes = append(attributes, stateAttribute(key+".#", strconv.Itoa(counts[e.key])))
+		}
+
+		nested, _ := e.value.(stateObject)
+		attributes = append(attributes, flattenState(key+"."+strconv.Itoa(blocks[e.key])+".", nested)...)
+		blocks[e.key]++
+	}
+
+	return attributes
+}
+
+func flattenStateValue(key string, v interface{}) []*Attribute {
+	switch v := v.(type) {
+	case string:
+		return []*Attribute{stateAttribute(key, v)}
+	case stateScalar:
+		if v == stateSensitive {
+			computed := "<sensitive>"
+			return []*Attribute{{Key: &key, Computed: &computed}}
+		}
+		return []*Attribute{stateAttribute(key, string(v))}
+	case stateJSON:
+		encoded, err := json.Marshal(stateToJSON(v.value))
+		if err != nil {
+			return nil
+		}
+		return []*Attribute{stateAttribute(key, string(encoded))}
+	case []interface{}:
+		attributes := []*Attribute{stateAttribute(key+".#", strconv.Itoa(len(v)))}
+		for i, element := range v {
+			elementKey := key + "." + strconv.Itoa(i)
+
+			// Objects in lists are not counted like maps
+			if o, ok := element.(stateObject); ok {
+				attributes = append(attributes, flattenState(elementKey+".", o)...)
+			} else {
+				attributes = append(attributes, flattenStateValue(elementKey, element)...)
+			}
+		}
+		return attributes
+	case stateObject:
+		attributes := []*Attribute{stateAttribute(key+".%", strconv.Itoa(len(v)))}
+		return append(attributes, flattenState(key+".", v)...)
+	}
+
+	// Null values are left out like Terraform does
+	return nil
+}
+
+func stateAttribute(key, value string) *Attribute {
+	return &Attribute{Key: &key, Value: &value}
+}
+
+// stateToJSON converts a value of the state to the value encoded as JSON.
+func stateToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case stateScalar:
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+			return decoded
+		}
+		return string(v)
+	case stateJSON:
+		return stateToJSON(v.value)
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, element := range v {
+			l[i] = stateToJSON(element)
+		}
+		return l
+	case stateObject:
+		m := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			m[e.key] = stateToJSON(e.value)
+		}
+		return m
+	}
+
+	return v
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// headerRE matches the line starting a resource, e.g.
+	// `-/+ aws_instance.web (new resource required)`.
+	headerRE = regexp.MustCompile(`^\s*(\+|-|~|-/\+|<=) \S+( \([^)]*\))*\s*$`)
+
+	// startRE matches the lines after which Terraform lists the resources.
+	startRE = regexp.MustCompile(`^(Terraform will perform the following actions:|Path:)`)
+
+	// legendRE matches the line introducing the legend of change symbols,
+	// which would otherwise be mistaken for resource headers.
+	legendRE = regexp.MustCompile(`Resource actions are indicated with the following symbols`)
+
+	streamANSIRE = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
+)
+
+// ChunkReader splits a plan into chunks that can be parsed on their own so
+// that huge plans can be printed as they are read without holding them in
+// memory. The first chunk holds everything up to and including the first
+// resource (the preface is only stripped properly when seen as a whole), and
+// every following chunk holds a single resource, the last one being followed
+// by the plan summary.
+type ChunkReader struct {
+	reader *bufio.Reader
+	err    error
+
+	chunk     strings.Builder
+	read      int
+	hasHeader bool
+	started   bool
+	legend    bool
+	drift     bool
+
+	// modern is set once a resource displayed the way Terraform 0.12 and
+	// later does has been read, whose lines could be mistaken for resource
+	// headers of Terraform 0.11.
+	modern bool
+}
+
+// NewChunkReader returns a ChunkReader reading the plan from r.
+func NewChunkReader(r io.Reader) *ChunkReader {
+	return &ChunkReader{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next chunk of the plan. It returns io.EOF once the whole
+// plan has been read, or the error that stopped the reading once the chunk
+// read so far has been returned.
+func (c *ChunkReader) Next() (string, error) {
+	for c.err == nil {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.err = err
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if c.isBoundary(line) {
+			chunk := c.chunk.String()
+			c.chunk.Reset()
+			c.chunk.WriteString(line)
+			c.read++
+
+			return chunk, nil
+		}
+
+		c.chunk.WriteString(line)
+	}
+
+	if c.chunk.Len() > 0 {
+		chunk := c.chunk.String()
+		c.chunk.Reset()
+		c.read++
+
+		return chunk, nil
+	}
+
+	return "", c.err
+}
+
+// First reports whether the chunk last returned by Next is the first chunk of
+// the plan, the only one holding its preface.
+func (c *ChunkReader) First() bool {
+	return c.read == 1
+}
+
+// Last reports whether the chunk last returned by Next is the last chunk of
+// the plan, the only one holding its summary.
+func (c *ChunkReader) Last() bool {
+	return c.err != nil && c.chunk.Len() == 0
+}
+
+// isBoundary reports whether the line starts a new chunk, keeping track of
+// where in the plan the reader is.
+func (c *ChunkReader) isBoundary(line string) bool {
+	plain := streamANSIRE.ReplaceAllString(strings.TrimRight(line, "\r\n"), "")
+
+	if !c.started {
+		// Changes made outside of Terraform are displayed as diffs whose
+		// lines could be mistaken for resource headers
+		if c.drift {
+			if !driftEndRE.MatchString(plain) {
+				return false
+			}
+			c.drift = false
+		}
+
+		switch {
+		case driftStartRE.MatchString(plain):
+			c.drift = true
+		case legendRE.MatchString(plain):
+			c.legend = true
+		case startRE.MatchString(plain):
+			c.started = true
+		case !c.legend && (headerRE.MatchString(plain) || actionHeaderRE.MatchString(plain)):
+			// Plans without a preface start with their first resource
+			c.started = true
+			c.hasHeader = true
+			c.modern = actionHeaderRE.MatchString(plain)
+		}
+
+		return false
+	}
+
+	if actionHeaderRE.MatchString(plain) {
+		c.modern = true
+	} else if c.modern || !headerRE.MatchString(plain) {
+		return false
+	}
+
+	if !c.hasHeader {
+		c.hasHeader = true
+		return false
+	}
+
+	return true
+}
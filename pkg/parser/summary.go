@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// CountChanges computes the summary of the plan from the headers of its
+// resources. Replaced resources count as both added and destroyed, and data
+// sources being read are not counted, like Terraform does. Moved resources are
+// counted although Terraform does not.
+func (p *Plan) CountChanges() Metadata {
+	var m Metadata
+
+	for _, r := range p.Resources {
+		switch *r.Header.Change {
+		case "+":
+			m.Add++
+		case "~":
+			m.Change++
+		case "-":
+			m.Destroy++
+		case "-/+":
+			m.Add++
+			m.Destroy++
+		case ImportChange:
+			m.Import++
+		case ForgetChange:
+			m.Forget++
+		case MoveChange:
+			m.Move++
+		}
+
+		// Resources may be moved or imported along with another change
+		if r.Header.MovedFrom != "" && *r.Header.Change != MoveChange {
+			m.Move++
+		}
+		if r.Header.ImportedFrom != "" && *r.Header.Change != ImportChange {
+			m.Import++
+		}
+	}
+
+	return m
+}
+
+// CheckSummary returns an error if the summary printed by Terraform disagrees
+// with the resources that were parsed, which is a sign of truncated input or
+// of resources the parser does not understand. Plans without a summary are
+// not checked.
+func (p *Plan) CheckSummary() error {
+	return CompareSummary(p.CountChanges(), p.Metadata)
+}
+
+// CompareSummary returns an error if the summary printed by Terraform
+// disagrees with the changes counted from the resources of a plan (see
+// Plan.CountChanges). A nil summary is not compared.
+func CompareSummary(counted Metadata, summary *Metadata) error {
+	if summary == nil {
+		return nil
+	}
+
+	if counted.Add == summary.Add && counted.Change == summary.Change && counted.Destroy == summary.Destroy &&
+		counted.Import == summary.Import && counted.Forget == summary.Forget {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"the plan summary (%s) does not match the resources shown (%s), the input may be incomplete",
+		summary, counted,
+	)
+}
+
+// String returns the counts of the summary like Terraform prints them, e.g.
+// `1 to import, 0 to add, 0 to change, 0 to destroy`.
+func (m Metadata) String() string {
+	s := fmt.Sprintf("%d to add, %d to change, %d to destroy", m.Add, m.Change, m.Destroy)
+
+	if m.Import > 0 {
+		s = fmt.Sprintf("%d to import, %s", m.Import, s)
+	}
+
+	if m.Forget > 0 {
+		s = fmt.Sprintf("%s, %d to forget", s, m.Forget)
+	}
+
+	return s
+}
+
+// DriftSummary counts the resources changed outside of Terraform.
+type DriftSummary struct {
+	Changed int
+	Deleted int
+}
+
+// CountDrift counts the resources Terraform detected had changed outside of
+// Terraform.
+func (p *Plan) CountDrift() DriftSummary {
+	var d DriftSummary
+
+	for _, r := range p.Drift {
+		switch *r.Header.Change {
+		case "~":
+			d.Changed++
+		case "-":
+			d.Deleted++
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// modulePrefixRE matches the prefix Terragrunt adds to the lines output for
+// each module by `run-all` commands, e.g. `[envs/prod/vpc] `.
+var modulePrefixRE = regexp.MustCompile(`^(?:\x1b\[[0-9;]*m)*\[([^\]]+)\](?:\x1b\[[0-9;]*m)*(?: |$)`)
+
+// terragruntModule is the prefix of Terragrunt's own log lines.
+const terragruntModule = "terragrunt"
+
+// ModuleOutput is the output of a single module of a Terragrunt `run-all`
+// command.
+type ModuleOutput struct {
+	Module string
+	Output string
+}
+
+// ModuleOf returns the module a line of Terragrunt output is prefixed with.
+// Terragrunt's own log lines do not belong to any module.
+func ModuleOf(line string) (string, bool) {
+	m := modulePrefixRE.FindStringSubmatch(line)
+	if m == nil || m[1] == terragruntModule {
+		return "", false
+	}
+
+	return m[1], true
+}
+
+// SplitModules demultiplexes the interleaved output of a Terragrunt `run-all`
+// command into the output of each module, with the prefixes stripped, in the
+// order the modules first appear. Lines without a module prefix (e.g. values
+// spanning several lines) belong to the module of the line before them, or
+// to the first module if no module precedes them. Terragrunt's own log lines
+// are dropped.
+func SplitModules(input string) []ModuleOutput {
+	var modules []ModuleOutput
+	var outputs []*strings.Builder
+	var leading strings.Builder
+	index := map[string]int{}
+	current := -1
+
+	for _, line := range strings.SplitAfter(input, "\n") {
+		text := strings.TrimSuffix(line, "\n")
+
+		if m := modulePrefixRE.FindStringSubmatch(text); m != nil && m[1] == terragruntModule {
+			continue
+		}
+
+		module, ok := ModuleOf(text)
+		if !ok {
+			if current < 0 {
+				leading.WriteString(line)
+			} else {
+				outputs[current].WriteString(line)
+			}
+			continue
+		}
+
+		i, ok := index[module]
+		if !ok {
+			i = len(modules)
+			index[module] = i
+			modules = append(modules, ModuleOutput{Module: module})
+			outputs = append(outputs, &strings.Builder{})
+			if i == 0 {
+				outputs[i].WriteString(leading.String())
+			}
+		}
+		current = i
+
+		outputs[i].WriteString(text[len(modulePrefixRE.FindString(text)):])
+		if text != line {
+			outputs[i].WriteString("\n")
+		}
+	}
+
+	if len(modules) == 0 && leading.Len() > 0 {
+		return []ModuleOutput{{Output: leading.String()}}
+	}
+
+	for i := range modules {
+		modules[i].Output = outputs[i].String()
+	}
+
+	return modules
+}
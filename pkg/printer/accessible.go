@@ -0,0 +1,89 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// actionWords are the words resource changes are spelled out as in
+// accessible mode.
+var actionWords = map[string]string{
+	"+":   "CREATE",
+	"-":   "DESTROY",
+	"~":   "UPDATE",
+	"-/+": "REPLACE",
+	"<=":  "READ",
+
+	parser.MoveChange:   "MOVE",
+	parser.ImportChange: "IMPORT",
+	parser.ForgetChange: "FORGET",
+}
+
+// attributeAction returns the word describing what happens to the attribute
+// of a resource with the given change.
+func attributeAction(change string, a *parser.Attribute) string {
+	switch change {
+	case "+", "-", "<=", parser.ImportChange, parser.ForgetChange:
+		return actionWords[change]
+	}
+
+	switch {
+	case a.NewResource:
+		return "FORCES REPLACEMENT"
+	case isChanged(a):
+		return "UPDATE"
+	}
+
+	return "UNCHANGED"
+}
+
+// accessibleActions returns the words describing the change of each
+// attribute, padded to the same width so that values remain aligned.
+func accessibleActions(change string, attributes []*parser.Attribute) []string {
+	var width int
+	for _, a := range attributes {
+		if l := len(attributeAction(change, a)); l > width {
+			width = l
+		}
+	}
+
+	actions := make([]string, len(attributes))
+	for i, a := range attributes {
+		actions[i] = fmt.Sprintf("%-*s", width, attributeAction(change, a))
+	}
+
+	return actions
+}
+
+// accessibleAttributes returns copies of the attributes whose keys are
+// prefixed with the words describing their change (see accessibleActions).
+func accessibleAttributes(attributes []*parser.Attribute, actions []string) []*parser.Attribute {
+	prefixed := make([]*parser.Attribute, len(attributes))
+	for i, a := range attributes {
+		key := fmt.Sprintf("%s %s", actions[i], *a.Key)
+
+		p := *a
+		p.Key = &key
+		prefixed[i] = &p
+	}
+
+	return prefixed
+}
+
+// prefixContinuationLines prefixes the continuation lines of a printed
+// attribute (e.g. of multiline values) with the word describing its change,
+// in place of their indentation, so that every line is spelled out.
+func prefixContinuationLines(text, action string) string {
+	padding := attributeIndentation + strings.Repeat(" ", len(action))
+
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], padding) && strings.TrimSpace(lines[i]) != "" {
+			lines[i] = attributeIndentation + action + lines[i][len(padding):]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
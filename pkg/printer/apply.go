@@ -0,0 +1,136 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// slowestCount is the number of resources listed as the slowest to apply.
+const slowestCount = 5
+
+// appliedWords describe the outcome of each change once applied.
+var appliedWords = map[string]string{
+	"+":   "created",
+	"-":   "destroyed",
+	"~":   "modified",
+	"-/+": "replaced",
+	"<=":  "read",
+}
+
+// FprintApply prints the changes applied to each resource in the order they
+// were started, followed by the slowest resources and the summary of the
+// apply.
+func FprintApply(w io.Writer, a *parser.Apply) {
+	for _, r := range a.Resources {
+		printAppliedResource(w, r)
+	}
+
+	if len(a.Errors) > 0 {
+		if len(a.Resources) > 0 {
+			fmt.Fprintln(w)
+		}
+
+		for _, err := range a.Errors {
+			fmt.Fprintln(w, theme.Destroy.Sprint(fmt.Sprintf("Error: %s", err)))
+		}
+	}
+
+	printSlowest(w, a.Resources)
+
+	if a.Summary != nil {
+		fmt.Fprintln(w)
+		printApplySummary(w, a.Summary)
+	}
+}
+
+func printAppliedResource(w io.Writer, r *parser.AppliedResource) {
+	name := r.Address
+	header := &parser.Header{Change: &r.Change, Name: &name}
+
+	var outcome string
+	switch {
+	case len(r.Errors) > 0:
+		outcome = theme.Destroy.Sprint(fmt.Sprintf("failed after %s", r.Elapsed))
+	case r.Complete:
+		outcome = fmt.Sprintf("%s in %s", appliedWords[r.Change], r.Elapsed)
+	default:
+		outcome = theme.Warning.Sprint(fmt.Sprintf("incomplete after %s", r.Elapsed))
+	}
+
+	if r.ID != "" {
+		outcome = fmt.Sprintf("%s (id: %s)", outcome, r.ID)
+	}
+
+	printHeader(w, header, actionStyle(r.Change), outcome)
+
+	for _, err := range r.Errors {
+		fmt.Fprintf(w, "    %s\n", theme.Destroy.Sprint(err))
+	}
+}
+
+// printSlowest lists the resources that took the longest to apply, if more
+// than one resource was applied.
+func printSlowest(w io.Writer, resources []*parser.AppliedResource) {
+	var timed []*parser.AppliedResource
+	for _, r := range resources {
+		if r.Elapsed > 0 {
+			timed = append(timed, r)
+		}
+	}
+
+	if len(timed) < 2 {
+		return
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Elapsed > timed[j].Elapsed
+	})
+	if len(timed) > slowestCount {
+		timed = timed[:slowestCount]
+	}
+
+	var maxLength int
+	for _, r := range timed {
+		if l := len(r.Elapsed.String()); l > maxLength {
+			maxLength = l
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Slowest resources:")
+	for _, r := range timed {
+		fmt.Fprintf(w, "  %*s  %s\n", maxLength, r.Elapsed, linkResourceType(r.Address))
+	}
+}
+
+func printApplySummary(w io.Writer, s *parser.ApplySummary) {
+	var added, changed, destroyed string
+
+	if s.Added > 0 {
+		added = theme.Create.Sprint(fmt.Sprintf("%d added", s.Added))
+	} else {
+		added = "0 added"
+	}
+
+	if s.Changed > 0 {
+		changed = theme.Update.Sprint(fmt.Sprintf("%d changed", s.Changed))
+	} else {
+		changed = "0 changed"
+	}
+
+	if s.Destroyed > 0 {
+		destroyed = theme.Destroy.Sprint(fmt.Sprintf("%d destroyed", s.Destroyed))
+	} else {
+		destroyed = "0 destroyed"
+	}
+
+	if s.Verb == "Destroy" {
+		fmt.Fprintf(w, "Destroy complete! Resources: %s.\n", destroyed)
+		return
+	}
+
+	fmt.Fprintf(w, "Apply complete! Resources: %s, %s, %s.\n", added, changed, destroyed)
+}
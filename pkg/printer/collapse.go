@@ -0,0 +1,101 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+const computedValue = "<computed>"
+
+// collapsedAttributes counts the attributes of a resource that are not shown
+// individually because of the HideComputed and OnlyChanged options.
+type collapsedAttributes struct {
+	Computed  int
+	Unchanged int
+}
+
+// filterAttributes returns the attributes of the resource that should be
+// printed along with the number of attributes that were collapsed.
+func filterAttributes(r *parser.Resource) ([]*parser.Attribute, collapsedAttributes) {
+	var visible []*parser.Attribute
+	var collapsed collapsedAttributes
+
+	for _, a := range r.Attributes {
+		switch {
+		case options.HideComputed && isComputedOnly(a):
+			collapsed.Computed++
+		case options.OnlyChanged && !isChanged(a) && !changesAllValues(*r.Header.Change):
+			collapsed.Unchanged++
+		default:
+			visible = append(visible, a)
+		}
+	}
+
+	return visible, collapsed
+}
+
+// changesAllValues reports whether every value of resources with the given change is
+// a change, i.e. of resources being created or destroyed.
+func changesAllValues(change string) bool {
+	return change == "+" || change == "-"
+}
+
+// isComputedOnly reports whether the attribute value is only known after
+// apply.
+func isComputedOnly(a *parser.Attribute) bool {
+	return (a.Computed != nil && *a.Computed == computedValue) ||
+		(a.AfterComputed != nil && *a.AfterComputed == computedValue && *a.Before == "")
+}
+
+// isChanged reports whether the attribute displays a change between two
+// values.
+func isChanged(a *parser.Attribute) bool {
+	if a.Before == nil {
+		return false
+	}
+
+	if a.AfterComputed != nil {
+		return true
+	}
+
+	return a.After != nil && *a.Before != *a.After
+}
+
+func (c collapsedAttributes) empty() bool {
+	return c.Computed == 0 && c.Unchanged == 0
+}
+
+// lines describes the collapsed attributes, e.g. "12 computed attributes".
+// Attributes of resources that are not being updated are not described as
+// unchanged since every value of such resources is new (or gone).
+func (c collapsedAttributes) lines(change string) []string {
+	var lines []string
+
+	if c.Unchanged > 0 {
+		kind := ""
+		if change == "~" || change == "-/+" {
+			kind = "unchanged "
+		}
+		lines = append(lines, fmt.Sprintf("%d %s%s", c.Unchanged, kind, pluralize("attribute", c.Unchanged)))
+	}
+
+	if c.Computed > 0 {
+		lines = append(lines, fmt.Sprintf("%d computed %s", c.Computed, pluralize("attribute", c.Computed)))
+	}
+
+	return lines
+}
+
+func (c collapsedAttributes) summary(change string) string {
+	return fmt.Sprintf("(%s)", strings.Join(c.lines(change), ", "))
+}
+
+func pluralize(word string, count int) string {
+	if count == 1 {
+		return word
+	}
+
+	return word + "s"
+}
@@ -0,0 +1,289 @@
+package printer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDecodingSteps bounds the decoder chain so that pathological values (e.g.
+// base64 of base64 of ...) cannot keep the printer busy.
+const maxDecodingSteps = 8
+
+// decodedValue is the result of running an attribute value through the
+// decoder chain.
+type decodedValue struct {
+	// Text is the fully decoded value.
+	Text string
+
+	// Encodings lists the decodings that were applied, in order.
+	Encodings []string
+
+	// Format is the detected format of Text ("json", "yaml",
+	// "mime-multipart") or an empty string for plain text.
+	Format string
+}
+
+// decoder undoes a single encoding layer. It returns false if the input is not
+// encoded with the decoder's encoding.
+type decoder struct {
+	name   string
+	decode func([]byte) ([]byte, bool)
+}
+
+var decoders = []decoder{
+	{"base64", decodeBase64(base64.StdEncoding)},
+	{"base64url", decodeBase64(base64.URLEncoding)},
+	{"gzip", decodeGzip},
+	{"zlib", decodeZlib},
+	{"url", decodeURLEncodedJSON},
+	{"json-string", decodeEscapedJSON},
+}
+
+// decodeValue repeatedly applies the first matching decoder to value until
+// none of them match, and then detects the format of the decoded content.
+//
+// A decoding step is only kept if it results in either text or in data that
+// another decoder can make sense of (e.g. gzip compressed text), so binary
+// data is never returned.
+func decodeValue(value string) decodedValue {
+	result := decodedValue{Text: value}
+
+	data := []byte(value)
+	for step := 0; step < maxDecodingSteps; step++ {
+		decoded, name, ok := decodeStep(data)
+		if !ok {
+			break
+		}
+
+		data = decoded
+		result.Encodings = append(result.Encodings, name)
+	}
+
+	if len(result.Encodings) > 0 {
+		if !isText(data) {
+			return decodedValue{Text: value, Format: detectFormat(value)}
+		}
+		result.Text = string(data)
+	}
+
+	result.Format = detectFormat(result.Text)
+
+	return result
+}
+
+func decodeStep(data []byte) ([]byte, string, bool) {
+	for _, d := range decoders {
+		decoded, ok := d.decode(data)
+		if !ok {
+			continue
+		}
+
+		if isText(decoded) || isCompressed(decoded) {
+			return decoded, d.name, true
+		}
+
+		if _, _, ok := decodeStep(decoded); ok {
+			return decoded, d.name, true
+		}
+	}
+
+	return nil, "", false
+}
+
+func decodeBase64(encoding *base64.Encoding) func([]byte) ([]byte, bool) {
+	return func(data []byte) ([]byte, bool) {
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) == 0 {
+			return nil, false
+		}
+
+		decoded, err := encoding.DecodeString(string(trimmed))
+		if err != nil || len(decoded) == 0 {
+			return nil, false
+		}
+
+		return decoded, true
+	}
+}
+
+func decodeGzip(data []byte) ([]byte, bool) {
+	if !isGzip(data) {
+		return nil, false
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close() // nolint: errcheck
+
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, false
+	}
+
+	return decompressed, true
+}
+
+func decodeZlib(data []byte) ([]byte, bool) {
+	if !isZlib(data) {
+		return nil, false
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close() // nolint: errcheck
+
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, false
+	}
+
+	return decompressed, true
+}
+
+// decodeURLEncodedJSON unescapes URL-encoded JSON documents such as the IAM
+// policies returned by some providers (`%7B%22Version%22...`). Documents that
+// are already JSON are left alone since their values may contain `%` escapes
+// of their own.
+func decodeURLEncodedJSON(data []byte) ([]byte, bool) {
+	if !bytes.Contains(data, []byte("%")) || isJSONOrEscapedJSON(data) {
+		return nil, false
+	}
+
+	decoded, err := url.PathUnescape(string(bytes.TrimSpace(data)))
+	if err != nil || !isJSONOrEscapedJSON([]byte(decoded)) {
+		return nil, false
+	}
+
+	return []byte(decoded), true
+}
+
+// decodeEscapedJSON unescapes JSON documents that have been encoded as a JSON
+// string, either with (`"{\"Version\"...}"`) or without
+// (`{\"Version\"...}`) the surrounding quotes.
+func decodeEscapedJSON(data []byte) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.Contains(trimmed, []byte(`\"`)) {
+		return nil, false
+	}
+
+	quoted := trimmed
+	if len(trimmed) < 2 || trimmed[0] != '"' || trimmed[len(trimmed)-1] != '"' {
+		quoted = []byte(fmt.Sprintf(`"%s"`, trimmed))
+	}
+
+	var decoded string
+	if err := json.Unmarshal(quoted, &decoded); err != nil || !isJSONOrEscapedJSON([]byte(decoded)) {
+		return nil, false
+	}
+
+	return []byte(decoded), true
+}
+
+// isJSONOrEscapedJSON reports whether data is a JSON document or a JSON
+// document escaped into a JSON string, which the decoder chain will unescape
+// in a later step.
+func isJSONOrEscapedJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return false
+	}
+
+	switch trimmed[0] {
+	case '{', '[':
+		return json.Valid(trimmed) || bytes.Contains(trimmed, []byte(`\"`))
+	case '"':
+		return json.Valid(trimmed)
+	}
+
+	return false
+}
+
+func isGzip(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// isZlib checks the zlib header: a deflate compression method and a header
+// checksum that is a multiple of 31 (RFC 1950).
+func isZlib(data []byte) bool {
+	return len(data) > 2 && data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+}
+
+func isCompressed(data []byte) bool {
+	return isGzip(data) || isZlib(data)
+}
+
+// isText reports whether b is valid UTF-8 made up exclusively of printable
+// characters and whitespace.
+func isText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func detectFormat(text string) string {
+	trimmed := strings.TrimSpace(text)
+
+	switch {
+	case json.Valid([]byte(trimmed)) && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")):
+		return "json"
+	case isMIMEMultipart(text):
+		return "mime-multipart"
+	case isYAMLDocument(text):
+		return "yaml"
+	}
+
+	return ""
+}
+
+// isMIMEMultipart reports whether text is a MIME multipart message such as the
+// multipart user data consumed by cloud-init.
+func isMIMEMultipart(text string) bool {
+	if !strings.Contains(text, "Content-Type:") {
+		return false
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(text))
+	if err != nil {
+		return false
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != ""
+}
+
+// label describes the decodings applied to the value, or returns an empty
+// string if the value was not encoded.
+func (d decodedValue) label() string {
+	if len(d.Encodings) == 0 {
+		return ""
+	}
+
+	return theme.Label.Sprint(fmt.Sprintf("(decoded: %s)", strings.Join(d.Encodings, ", ")))
+}
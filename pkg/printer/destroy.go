@@ -0,0 +1,96 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// statefulTypes are the resource types holding data that is lost when they
+// are destroyed.
+var statefulTypes = map[string]bool{
+	"aws_db_instance":                   true,
+	"aws_docdb_cluster":                 true,
+	"aws_dynamodb_table":                true,
+	"aws_ebs_volume":                    true,
+	"aws_efs_file_system":               true,
+	"aws_elasticache_cluster":           true,
+	"aws_elasticache_replication_group": true,
+	"aws_elasticsearch_domain":          true,
+	"aws_glacier_vault":                 true,
+	"aws_kinesis_stream":                true,
+	"aws_kms_key":                       true,
+	"aws_neptune_cluster":               true,
+	"aws_rds_cluster":                   true,
+	"aws_redshift_cluster":              true,
+	"aws_s3_bucket":                     true,
+	"aws_secretsmanager_secret":         true,
+	"aws_sqs_queue":                     true,
+	"azurerm_cosmosdb_account":          true,
+	"azurerm_key_vault":                 true,
+	"azurerm_managed_disk":              true,
+	"azurerm_mysql_server":              true,
+	"azurerm_postgresql_server":         true,
+	"azurerm_redis_cache":               true,
+	"azurerm_sql_database":              true,
+	"azurerm_storage_account":           true,
+	"google_bigquery_dataset":           true,
+	"google_bigquery_table":             true,
+	"google_bigtable_instance":          true,
+	"google_compute_disk":               true,
+	"google_filestore_instance":         true,
+	"google_redis_instance":             true,
+	"google_spanner_database":           true,
+	"google_sql_database":               true,
+	"google_sql_database_instance":      true,
+	"google_storage_bucket":             true,
+}
+
+// printDestroyBanner lists the types of the resources destroyed by a destroy
+// run with their counts, stateful types first.
+func printDestroyBanner(w io.Writer, destroyed map[string]int) {
+	var types []string
+	total := 0
+	for t, n := range destroyed {
+		types = append(types, t)
+		total += n
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		if statefulTypes[types[i]] != statefulTypes[types[j]] {
+			return statefulTypes[types[i]]
+		}
+		if destroyed[types[i]] != destroyed[types[j]] {
+			return destroyed[types[i]] > destroyed[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	noun := "resources"
+	if total == 1 {
+		noun = "resource"
+	}
+
+	var maxTypeLength, maxCountLength int
+	for _, t := range types {
+		if l := len(t); l > maxTypeLength {
+			maxTypeLength = l
+		}
+		if l := len(fmt.Sprint(destroyed[t])); l > maxCountLength {
+			maxCountLength = l
+		}
+	}
+
+	fmt.Fprintln(w, theme.Risky.Sprint(fmt.Sprintf("DESTROY: this run destroys %d %s and creates nothing.", total, noun)))
+	fmt.Fprintln(w)
+
+	for _, t := range types {
+		line := fmt.Sprintf("  %*d  %-*s", maxCountLength, destroyed[t], maxTypeLength, t)
+		if statefulTypes[t] {
+			fmt.Fprintln(w, theme.Risky.Sprint(line+"  stateful, its data will be lost"))
+		} else {
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+}
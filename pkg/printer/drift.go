@@ -0,0 +1,67 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// DriftHeading returns the heading of the resources Terraform detected had
+// changed outside of Terraform.
+func DriftHeading() string {
+	return theme.Drift.Sprint("Objects changed outside of Terraform:")
+}
+
+// PlannedHeading returns the heading of the changes Terraform plans to make,
+// which follow the changes made outside of Terraform.
+func PlannedHeading() string {
+	return theme.Heading.Sprint("Changes planned by Terraform:")
+}
+
+// FprintDriftResource prints a single resource changed outside of Terraform
+// to w
+func FprintDriftResource(w io.Writer, r *parser.Resource) {
+	printResourceWithStyle(w, r, theme.Drift)
+}
+
+// printDrift prints the resources Terraform detected had changed outside of
+// Terraform under their own heading.
+func printDrift(w io.Writer, drift []*parser.Resource) {
+	fmt.Fprintln(w, DriftHeading())
+	fmt.Fprintln(w)
+
+	for _, r := range drift {
+		FprintDriftResource(w, r)
+	}
+
+	if options.Compact {
+		fmt.Fprintln(w)
+	}
+}
+
+// printDriftSummary prints the counts of resources changed outside of
+// Terraform, if any.
+func printDriftSummary(w io.Writer, d parser.DriftSummary) {
+	if d.Changed == 0 && d.Deleted == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Drift: %s outside of Terraform.\n", formatDrift(d))
+}
+
+// formatDrift returns the counts of resources changed outside of Terraform,
+// e.g. `1 changed, 0 deleted`, colored when not zero.
+func formatDrift(d parser.DriftSummary) string {
+	changed := fmt.Sprintf("%d changed", d.Changed)
+	if d.Changed > 0 {
+		changed = theme.Drift.Sprint(changed)
+	}
+
+	deleted := fmt.Sprintf("%d deleted", d.Deleted)
+	if d.Deleted > 0 {
+		deleted = theme.Drift.Sprint(deleted)
+	}
+
+	return fmt.Sprintf("%s, %s", changed, deleted)
+}
@@ -0,0 +1,323 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// iamPolicy is a normalised representation of an IAM policy document where
+// every multi-valued field is a sorted list of strings.
+type iamPolicy struct {
+	Version    string
+	Statements []*iamStatement
+}
+
+type iamStatement struct {
+	ID     string
+	Effect string
+	Fields map[string][]string
+}
+
+// iamStatementFields is the order in which statement fields are rendered.
+var iamStatementFields = []string{
+	"action",
+	"not_action",
+	"resource",
+	"not_resource",
+	"principal",
+	"not_principal",
+	"condition",
+}
+
+// parseIAMPolicy returns the normalised IAM policy represented by value. The
+// second return value is false if the value does not look like an IAM policy
+// document (a JSON object with both "Version" and "Statement" keys).
+func parseIAMPolicy(value string) (*iamPolicy, bool) {
+	var document map[string]interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+
+	version, hasVersion := document["Version"]
+	rawStatements, hasStatement := document["Statement"]
+	if !hasVersion || !hasStatement {
+		return nil, false
+	}
+
+	// A policy with a single statement may omit the surrounding list.
+	var statements []interface{}
+	switch s := rawStatements.(type) {
+	case []interface{}:
+		statements = s
+	case map[string]interface{}:
+		statements = []interface{}{s}
+	default:
+		return nil, false
+	}
+
+	policy := &iamPolicy{Version: fmt.Sprint(version)}
+
+	for i, s := range statements {
+		rawStatement, ok := s.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		statement := &iamStatement{
+			ID:     fmt.Sprintf("#%d", i+1),
+			Effect: fmt.Sprint(rawStatement["Effect"]),
+			Fields: map[string][]string{
+				"action":        normaliseStringList(rawStatement["Action"]),
+				"not_action":    normaliseStringList(rawStatement["NotAction"]),
+				"resource":      normaliseStringList(rawStatement["Resource"]),
+				"not_resource":  normaliseStringList(rawStatement["NotResource"]),
+				"principal":     normalisePrincipals(rawStatement["Principal"]),
+				"not_principal": normalisePrincipals(rawStatement["NotPrincipal"]),
+				"condition":     normaliseConditions(rawStatement["Condition"]),
+			},
+		}
+
+		if sid, ok := rawStatement["Sid"].(string); ok && sid != "" {
+			statement.ID = fmt.Sprintf("%q", sid)
+		}
+
+		policy.Statements = append(policy.Statements, statement)
+	}
+
+	return policy, true
+}
+
+// normaliseStringList converts a field that may either be a single string or a
+// list of strings into a sorted list without duplicates.
+func normaliseStringList(v interface{}) []string {
+	var values []string
+
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, e := range t {
+			values = append(values, fmt.Sprint(e))
+		}
+	default:
+		values = append(values, fmt.Sprint(t))
+	}
+
+	return sortedSet(values)
+}
+
+// normalisePrincipals flattens a principal block into "Type:Value" entries.
+//
+// Example:
+//
+//	`{"AWS": ["arn:aws:iam::123456789012:root"]}` => `AWS:arn:aws:iam::123456789012:root`
+func normalisePrincipals(v interface{}) []string {
+	principals, ok := v.(map[string]interface{})
+	if !ok {
+		return normaliseStringList(v)
+	}
+
+	var values []string
+	for principalType, p := range principals {
+		for _, principal := range normaliseStringList(p) {
+			values = append(values, fmt.Sprintf("%s:%s", principalType, principal))
+		}
+	}
+
+	return sortedSet(values)
+}
+
+// normaliseConditions flattens a condition block into "Operator Key = Value"
+// entries.
+//
+// Example:
+//
+//	`{"StringEquals": {"aws:SourceVpc": "vpc-1a2b3c4d"}}` => `StringEquals aws:SourceVpc = vpc-1a2b3c4d`
+func normaliseConditions(v interface{}) []string {
+	conditions, ok := v.(map[string]interface{})
+	if !ok {
+		return normaliseStringList(v)
+	}
+
+	var values []string
+	for operator, c := range conditions {
+		keys, ok := c.(map[string]interface{})
+		if !ok {
+			values = append(values, fmt.Sprintf("%s %v", operator, c))
+			continue
+		}
+
+		for key, k := range keys {
+			for _, value := range normaliseStringList(k) {
+				values = append(values, fmt.Sprintf("%s %s = %s", operator, key, value))
+			}
+		}
+	}
+
+	return sortedSet(values)
+}
+
+func sortedSet(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(values))
+	var set []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			set = append(set, v)
+		}
+	}
+
+	sort.Strings(set)
+
+	return set
+}
+
+// setDifference returns the elements of a that are not present in b.
+func setDifference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+	}
+
+	var diff []string
+	for _, v := range a {
+		if !inB[v] {
+			diff = append(diff, v)
+		}
+	}
+
+	return diff
+}
+
+// diffIAMPolicies returns a per-statement summary of the changes between two
+// policies. Statements are matched by Sid, or by position for statements
+// without one.
+func diffIAMPolicies(before, after *iamPolicy) []string {
+	var lines []string
+
+	if before.Version != after.Version {
+		lines = append(lines, theme.Modified.Sprint(fmt.Sprintf("~ version: %s => %s", before.Version, after.Version)))
+	}
+
+	beforeStatements := make(map[string]*iamStatement, len(before.Statements))
+	for _, s := range before.Statements {
+		beforeStatements[s.ID] = s
+	}
+
+	afterStatements := make(map[string]*iamStatement, len(after.Statements))
+	for _, s := range after.Statements {
+		afterStatements[s.ID] = s
+	}
+
+	for _, s := range before.Statements {
+		if _, ok := afterStatements[s.ID]; !ok {
+			lines = append(lines, theme.Removed.Sprint(fmt.Sprintf("- statement %s (%s)", s.ID, s.Effect)))
+			lines = append(lines, iamFieldLines(s.Fields, nil)...)
+		}
+	}
+
+	for _, s := range after.Statements {
+		old, ok := beforeStatements[s.ID]
+		if !ok {
+			lines = append(lines, theme.Added.Sprint(fmt.Sprintf("+ statement %s (%s)", s.ID, s.Effect)))
+			lines = append(lines, iamFieldLines(nil, s.Fields)...)
+			continue
+		}
+
+		fieldLines := iamFieldLines(old.Fields, s.Fields)
+		if old.Effect == s.Effect && len(fieldLines) == 0 {
+			continue
+		}
+
+		lines = append(lines, theme.Modified.Sprint(fmt.Sprintf("~ statement %s (%s)", s.ID, s.Effect)))
+		if old.Effect != s.Effect {
+			lines = append(lines, theme.Modified.Sprint(fmt.Sprintf("    ~ %-14s %s => %s", "effect:", old.Effect, s.Effect)))
+		}
+		lines = append(lines, fieldLines...)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "(no effective policy changes)")
+	}
+
+	return lines
+}
+
+func iamFieldLines(before, after map[string][]string) []string {
+	var lines []string
+
+	for _, field := range iamStatementFields {
+		label := fmt.Sprintf("%s:", field)
+
+		for _, v := range setDifference(before[field], after[field]) {
+			lines = append(lines, fmt.Sprintf("    %s %s", theme.Removed.Sprint(fmt.Sprintf("- %-14s", label)), formatIAMValue(field, v, theme.Removed.Sprint)))
+		}
+
+		for _, v := range setDifference(after[field], before[field]) {
+			lines = append(lines, fmt.Sprintf("    %s %s", theme.Added.Sprint(fmt.Sprintf("+ %-14s", label)), formatIAMValue(field, v, theme.Added.Sprint)))
+		}
+	}
+
+	return lines
+}
+
+// iamServiceWildcardRE matches actions granting every action of a service,
+// e.g. `s3:*`.
+var iamServiceWildcardRE = regexp.MustCompile(`^[a-zA-Z0-9-]+:\*$`)
+
+// isIAMWildcard reports whether the value of a statement field grants access
+// to anything: every action (of a service), every resource or every
+// principal. Scoped values such as `arn:aws:s3:::bucket/*` are not.
+func isIAMWildcard(field, value string) bool {
+	switch field {
+	case "action", "not_action", "principal", "not_principal":
+		// Principals are flattened to `Type:Value`, e.g. `AWS:*`
+		return value == "*" || iamServiceWildcardRE.MatchString(value)
+	case "resource", "not_resource":
+		return value == "*"
+	}
+
+	return false
+}
+
+// formatIAMValue highlights values granting access through wildcards since
+// those are usually the riskiest part of a policy change.
+func formatIAMValue(field, value string, printer func(a ...interface{}) string) string {
+	if isIAMWildcard(field, value) {
+		return fmt.Sprintf("%s %s", theme.Risky.Sprint(value), theme.Risky.Sprint("(wildcard)"))
+	}
+
+	return printer(value)
+}
+
+func printPolicyAttribute(w io.Writer, key string, before, after *iamPolicy, label string, maxKeyLength int) {
+	printModifier := fmt.Sprintf("%%s%%-%ds ", maxKeyLength)
+
+	fmt.Fprintf(w, printModifier, attributeIndentation, fmt.Sprintf("%s:", key))
+
+	// 4 (attribute padding) + 1 (key/value space separation)
+	padding := strings.Repeat(" ", maxKeyLength+4+1)
+
+	// The label takes the place of the first summary line
+	if label != "" {
+		fmt.Fprintln(w, label)
+	}
+
+	for i, l := range diffIAMPolicies(before, after) {
+		if i > 0 || label != "" {
+			fmt.Fprint(w, padding)
+		}
+		fmt.Fprintln(w, l)
+	}
+}
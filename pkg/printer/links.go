@@ -0,0 +1,83 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultDocsURL is the documentation URL template of resources whose
+// provider has no template in Options.DocsURLTemplates.
+const defaultDocsURL = "https://registry.terraform.io/providers/hashicorp/{provider}/latest/docs/{kind}/{name}"
+
+// resourceType locates the type in a resource address, skipping the module
+// path and the "data." prefix of data sources.
+//
+// Example:
+//
+//	module.vpc.aws_subnet.private[0] => aws_subnet (offset 11)
+func resourceType(address string) (resourceType string, offset int, data bool) {
+	segments := strings.Split(address, ".")
+
+	i := 0
+	for i+1 < len(segments) && segments[i] == "module" {
+		i += 2
+	}
+
+	if i+1 < len(segments) && segments[i] == "data" {
+		data = true
+		i++
+	}
+
+	if i >= len(segments) || !strings.Contains(segments[i], "_") {
+		return "", 0, false
+	}
+
+	for _, s := range segments[:i] {
+		offset += len(s) + 1
+	}
+
+	return segments[i], offset, data
+}
+
+// docsURL returns the documentation URL of the given resource type by filling
+// in the template of its provider.
+func docsURL(resourceType string, data bool) string {
+	provider := resourceType[:strings.Index(resourceType, "_")]
+
+	template, ok := options.DocsURLTemplates[provider]
+	if !ok {
+		template, ok = options.DocsURLTemplates["*"]
+	}
+	if !ok {
+		template = defaultDocsURL
+	}
+
+	kind := "resources"
+	if data {
+		kind = "data-sources"
+	}
+
+	return strings.NewReplacer(
+		"{provider}", provider,
+		"{type}", resourceType,
+		"{name}", strings.TrimPrefix(resourceType, provider+"_"),
+		"{kind}", kind,
+	).Replace(template)
+}
+
+// linkResourceType turns the resource type in the address into an OSC 8
+// hyperlink to its documentation when hyperlinks are enabled.
+func linkResourceType(address string) string {
+	if !options.Hyperlinks {
+		return address
+	}
+
+	t, offset, data := resourceType(address)
+	if t == "" {
+		return address
+	}
+
+	link := fmt.Sprintf("\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\", docsURL(t, data), t)
+
+	return address[:offset] + link + address[offset+len(t):]
+}
@@ -0,0 +1,162 @@
+package printer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// mimePart is a single part of a MIME multipart message.
+type mimePart struct {
+	// Key identifies the part across two versions of a message: the part's
+	// filename or, if it has none, its content type and position among the
+	// parts sharing that content type.
+	Key         string
+	ContentType string
+	Content     string
+}
+
+// parseMIMEParts splits a MIME multipart message into its parts. Parts that are
+// base64 encoded (or compressed) are decoded. Messages with a malformed part
+// are not split.
+func parseMIMEParts(text string) ([]*mimePart, bool) {
+	message, err := mail.ReadMessage(strings.NewReader(text))
+	if err != nil {
+		return nil, false
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, false
+	}
+
+	var parts []*mimePart
+	occurrences := map[string]int{}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Malformed parts would otherwise truncate the diff
+			return nil, false
+		}
+
+		body, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, false
+		}
+
+		contentType, _, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		if err != nil {
+			contentType = "text/plain"
+		}
+
+		content := string(body)
+		if strings.EqualFold(p.Header.Get("Content-Transfer-Encoding"), "base64") {
+			if decoded, err := base64.StdEncoding.DecodeString(content); err == nil {
+				content = string(decoded)
+			}
+		}
+
+		key := p.FileName()
+		if key == "" {
+			occurrences[contentType]++
+			key = fmt.Sprintf("%s #%d", contentType, occurrences[contentType])
+		}
+
+		parts = append(parts, &mimePart{
+			Key:         key,
+			ContentType: contentType,
+			Content:     decodeValue(content).Text,
+		})
+	}
+
+	return parts, len(parts) > 0
+}
+
+// diffMIMEMessages returns a diff of two MIME multipart messages made up of a
+// unified diff per part. Parts are matched by their key. If either message
+// cannot be split into parts the messages are diffed as plain text.
+func diffMIMEMessages(beforeText, afterText string) string {
+	before, beforeOK := parseMIMEParts(beforeText)
+	after, afterOK := parseMIMEParts(afterText)
+	if !beforeOK || !afterOK {
+		return unifiedDiff(beforeText, afterText)
+	}
+
+	beforeParts := make(map[string]*mimePart, len(before))
+	for _, p := range before {
+		beforeParts[p.Key] = p
+	}
+
+	afterParts := make(map[string]*mimePart, len(after))
+	for _, p := range after {
+		afterParts[p.Key] = p
+	}
+
+	var lines []string
+
+	for _, p := range before {
+		if _, ok := afterParts[p.Key]; !ok {
+			lines = append(lines, fmt.Sprintf("- part %s (%s)", p.Key, p.ContentType))
+			lines = append(lines, mimePartDiff(p.Content, "")...)
+		}
+	}
+
+	for _, p := range after {
+		old, ok := beforeParts[p.Key]
+		switch {
+		case !ok:
+			lines = append(lines, fmt.Sprintf("+ part %s (%s)", p.Key, p.ContentType))
+			lines = append(lines, mimePartDiff("", p.Content)...)
+		case old.Content == p.Content:
+			lines = append(lines, fmt.Sprintf("  part %s (%s) unchanged", p.Key, p.ContentType))
+		default:
+			lines = append(lines, theme.Modified.Sprint(fmt.Sprintf("~ part %s (%s)", p.Key, p.ContentType)))
+			lines = append(lines, mimePartDiff(old.Content, p.Content)...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// mimePartDiff returns the unified diff lines of a single part, without the
+// diff control lines.
+func mimePartDiff(before, after string) []string {
+	diff := difflib.UnifiedDiff{
+		A:       splitPartLines(before),
+		B:       splitPartLines(after),
+		Context: diffContext(),
+	}
+	diffText, _ := difflib.GetUnifiedDiffString(diff) // nolint: gosec
+
+	var lines []string
+	for _, l := range strings.Split(strings.TrimRight(diffText, "\n"), "\n") {
+		if strings.HasPrefix(l, "@@") && strings.HasSuffix(l, "@@") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+
+	return lines
+}
+
+// splitPartLines splits content into lines, treating the content of an added
+// or removed part as having no lines at all.
+func splitPartLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	return difflib.SplitLines(content)
+}
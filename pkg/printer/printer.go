@@ -1,59 +1,159 @@
 package printer
 
 import (
-	"encoding/base64"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"regexp"
 	"strings"
-	"unicode"
 
 	"github.com/dmlittle/scenery/pkg/parser"
-	"github.com/fatih/color"
 	"github.com/pmezard/go-difflib/difflib"
 )
 
+// Options holds the settings that control how plans are rendered.
+type Options struct {
+	// Reveal disables the redaction of detected secrets.
+	Reveal bool
+
+	// HideComputed collapses attributes only known after apply into a count.
+	HideComputed bool
+
+	// OnlyChanged collapses attributes that do not display a change into a
+	// count.
+	OnlyChanged bool
+
+	// Compact implies HideComputed and OnlyChanged and does not separate
+	// resources with blank lines.
+	Compact bool
+
+	// Width is the number of columns long values are wrapped to. Values are
+	// not wrapped if it is 0.
+	Width int
+
+	// NoTruncate disables the truncation of wrapped values spanning too many
+	// rows.
+	NoTruncate bool
+
+	// InlineDiffs prints changed values as `"before" => "after"` instead of
+	// as unified diffs or policy summaries.
+	InlineDiffs bool
+
+	// Accessible spells out the change of every resource and attribute (e.g.
+	// "CREATE", "FORCES REPLACEMENT"), including on the continuation lines of
+	// multiline values, and labels before and after values so that the output
+	// does not rely on colors or symbols. The plan summary, already in words,
+	// is printed as-is. It implies InlineDiffs.
+	Accessible bool
+
+	// DiffContext is the number of unchanged lines shown around changes in
+	// unified diffs, 0 showing only the changed lines. defaultDiffContext is
+	// used if it is nil.
+	DiffContext *int
+
+	// Hyperlinks turns resource types into OSC 8 hyperlinks to their
+	// documentation.
+	Hyperlinks bool
+
+	// DocsURLTemplates maps provider names (e.g. "aws") to the template of
+	// the documentation URL of their resources, "*" being used for providers
+	// without a template. Templates may refer to {provider}, {type} (e.g.
+	// "aws_instance"), {name} (e.g. "instance") and {kind} ("resources" or
+	// "data-sources").
+	DocsURLTemplates map[string]string
+
+	// Theme defines the colors of the output. The default theme is used if
+	// it is the zero value.
+	Theme Theme
+}
+
+// defaultDiffContext is the number of unchanged lines shown around changes in
+// unified diffs unless Options.DiffContext is set.
+const defaultDiffContext = 5
+
 var (
-	attributeIndentation = strings.Repeat(" ", 4)
+	options Options
 
-	greenSprintf  = color.New(color.FgGreen).SprintFunc()
-	redSprintf    = color.New(color.FgRed).SprintFunc()
-	yellowSprintf = color.New(color.FgYellow).SprintFunc()
+	attributeIndentation = strings.Repeat(" ", 4)
 )
 
-// PrettyPrint prints the Plan to stdout
-func PrettyPrint(p *parser.Plan) {
-	if p.Warnings != nil {
-		for _, w := range *p.Warnings {
-			color.Yellow(w)
-		}
-		fmt.Println()
+// SetOptions replaces the settings used by subsequent calls to PrettyPrint.
+func SetOptions(o Options) {
+	if o.Compact {
+		o.HideComputed = true
+		o.OnlyChanged = true
 	}
 
-	if p.NoChanges {
-		fmt.Println("No changes.")
-		return
+	if o.Accessible {
+		o.InlineDiffs = true
 	}
 
-	for _, r := range p.Resources {
-		printResource(r)
+	options = o
+
+	theme = o.Theme
+	if theme.Name == "" {
+		theme = themes["default"]
 	}
+}
+
+func diffContext() int {
+	if options.DiffContext != nil {
+		return *options.DiffContext
+	}
+
+	return defaultDiffContext
+}
 
-	printMetadata(p.Metadata)
+// CurrentOptions returns the settings used by PrettyPrint.
+func CurrentOptions() Options {
+	return options
 }
 
-func printResource(r *parser.Resource) {
-	c := getTypeColor(r.Header.Change)
+// PrettyPrint prints the Plan to stdout
+func PrettyPrint(p *parser.Plan) {
+	Fprint(os.Stdout, p)
+}
+
+// Fprint prints the Plan to w
+func Fprint(w io.Writer, p *parser.Plan) {
+	s := NewStream(w)
+	s.Print(p)
+	s.Close()
+}
 
-	printHeader(r.Header, c)
-	printAttributes(r.Attributes, c)
-	fmt.Println()
+// FprintResource prints a single resource of a Plan to w
+func FprintResource(w io.Writer, r *parser.Resource) {
+	printResource(w, r)
 }
 
-func printHeader(header *parser.Header, printer *color.Color) {
-	colorSprintf := printer.SprintFunc()
+func printResource(w io.Writer, r *parser.Resource) {
+	printResourceWithStyle(w, r, actionStyle(*r.Header.Change))
+}
 
-	fullName := *header.Name
+func printResourceWithStyle(w io.Writer, r *parser.Resource, c Style) {
+	attributes, collapsed := filterAttributes(r)
+
+	// Resources without any attribute left to show are summarised on a single
+	// line.
+	if len(attributes) == 0 && !collapsed.empty() {
+		printHeader(w, r.Header, c, collapsed.summary(*r.Header.Change))
+	} else {
+		printHeader(w, r.Header, c, "")
+		printAttributes(w, attributes, *r.Header.Change, c)
+		printCollapsedAttributes(w, collapsed, *r.Header.Change, c)
+	}
+
+	if !options.Compact {
+		fmt.Fprintln(w)
+	}
+}
+
+func printHeader(w io.Writer, header *parser.Header, style Style, summary string) {
+	colorSprintf := style.Sprint
+
+	fullName := linkResourceType(*header.Name)
 
 	if header.Taint {
 		fullName = fmt.Sprintf("%s (tainted)", fullName)
@@ -63,22 +163,43 @@ func printHeader(header *parser.Header, printer *color.Color) {
 		fullName = fmt.Sprintf("%s (new resource required)", fullName)
 	}
 
+	if header.MovedFrom != "" {
+		fullName = fmt.Sprintf("%s (moved from %s)", fullName, header.MovedFrom)
+	}
+
+	if header.ImportedFrom != "" {
+		fullName = fmt.Sprintf("%s (imported from %q)", fullName, header.ImportedFrom)
+	}
+
 	var changeSymbol string
-	if *header.Change == "-/+" {
-		changeSymbol = fmt.Sprintf("%s/%s", redSprintf("-"), greenSprintf("+"))
+	if options.Accessible {
+		changeSymbol = colorSprintf(actionWords[*header.Change])
+	} else if *header.Change == "-/+" {
+		changeSymbol = fmt.Sprintf("%s/%s", theme.Destroy.Sprint("-"), theme.Create.Sprint("+"))
 	} else {
 		changeSymbol = colorSprintf(*header.Change)
 	}
 
-	fmt.Printf("%s %s\n", changeSymbol, colorSprintf(fullName))
+	if summary != "" {
+		summary = " " + summary
+	}
+
+	fmt.Fprintf(w, "%s %s%s\n", changeSymbol, colorSprintf(fullName), summary)
 }
 
-func printAttributes(attributes []*parser.Attribute, printer *color.Color) {
+func printAttributes(w io.Writer, attributes []*parser.Attribute, change string, style Style) {
 	if len(attributes) == 0 {
 		return
 	}
 
-	colorSprintf := printer.SprintFunc()
+	colorSprintf := style.Sprint
+
+	// Attributes of the state (without change) need no spelling out
+	var actions []string
+	if options.Accessible && change != "" {
+		actions = accessibleActions(change, attributes)
+		attributes = accessibleAttributes(attributes, actions)
+	}
 
 	var maxAttributeLength int
 
@@ -91,20 +212,42 @@ func printAttributes(attributes []*parser.Attribute, printer *color.Color) {
 	// Account for the extra character taken my the colon (":") after the key name
 	maxAttributeLength++
 
-	for _, a := range attributes {
+	for i, a := range attributes {
+		a = redactedAttribute(a)
+
+		// Attributes are printed on their own to spell out their continuation
+		// lines in accessible mode
+		out := w
+		var buf bytes.Buffer
+		if actions != nil {
+			out = &buf
+		}
+
 		if a.Computed != nil {
-			printComputedAttribute(*a.Key, *a.Computed, maxAttributeLength, colorSprintf)
+			printComputedAttribute(out, *a.Key, *a.Computed, maxAttributeLength, colorSprintf)
 		} else if a.Value != nil {
-			printSimpleAttribute(*a.Key, *a.Value, maxAttributeLength, colorSprintf)
+			printSimpleAttribute(out, *a.Key, *a.Value, maxAttributeLength, colorSprintf)
 		} else if a.AfterComputed != nil {
-			printComplexAttribute(*a.Key, *a.Before, *a.AfterComputed, true, a.NewResource, maxAttributeLength)
+			printComplexAttribute(out, *a.Key, *a.Before, *a.AfterComputed, true, a.AttributeChanged, a.NewResource, maxAttributeLength)
 		} else if a.Before != nil && a.After != nil {
-			processComplexAttributes(a, maxAttributeLength)
+			processComplexAttributes(out, a, maxAttributeLength)
+		}
+
+		if actions != nil {
+			fmt.Fprint(w, prefixContinuationLines(buf.String(), actions[i]))
 		}
 	}
 }
 
-func processComplexAttributes(a *parser.Attribute, indentLength int) {
+func printCollapsedAttributes(w io.Writer, collapsed collapsedAttributes, change string, style Style) {
+	colorSprintf := style.Sprint
+
+	for _, l := range collapsed.lines(change) {
+		fmt.Fprintf(w, "%s%s\n", attributeIndentation, colorSprintf(fmt.Sprintf("(%s)", l)))
+	}
+}
+
+func processComplexAttributes(w io.Writer, a *parser.Attribute, indentLength int) {
 	isBeforeReference := isTerraformReference(a.Before)
 	isAfterReference := isTerraformReference(a.After)
 
@@ -112,104 +255,172 @@ func processComplexAttributes(a *parser.Attribute, indentLength int) {
 		return
 	}
 
-	if isBeforeReference != isAfterReference || (isBeforeReference && isAfterReference) || *a.Before == "" || *a.After == "" {
-		printComplexAttribute(*a.Key, *a.Before, *a.After, false, a.NewResource, indentLength)
+	if options.InlineDiffs || isBeforeReference != isAfterReference || (isBeforeReference && isAfterReference) || *a.Before == "" || *a.After == "" {
+		printComplexAttribute(w, *a.Key, *a.Before, *a.After, false, false, a.NewResource, indentLength)
 	} else {
-		bBefore := []byte(*a.Before)
-		bAfter := []byte(*a.After)
+		before := decodeValue(*a.Before)
+		after := decodeValue(*a.After)
 
-		isBeforeJSON := json.Valid(bBefore) && ((*a.Before)[0] == '{' || (*a.Before)[0] == '[')
-		isAfterJSON := json.Valid(bAfter) && ((*a.After)[0] == '{' || (*a.After)[0] == '[')
-
-		isBeforeBase64 := false
-		oldString, err := base64.StdEncoding.DecodeString(string(bBefore))
-		if err == nil {
-			isBeforeBase64 = true
-		}
+		before.Text = redactSecrets(before.Text)
+		after.Text = redactSecrets(after.Text)
 
-		isAfterBase64 := false
-		newString, err := base64.StdEncoding.DecodeString(string(bAfter))
-		if err == nil {
-			isAfterBase64 = true
+		label := after.label()
+		if label == "" {
+			label = before.label()
 		}
 
-		var old, new interface{}
-
-		beforeDecoder := json.NewDecoder(strings.NewReader(*a.Before))
-		beforeDecoder.UseNumber()
-		beforeDecoder.Decode(&old) // nolint:gosec
-
-		afterDecoder := json.NewDecoder(strings.NewReader(*a.After))
-		afterDecoder.UseNumber()
-		afterDecoder.Decode(&new) // nolint:gosec
-
-		oldPretty, _ := json.MarshalIndent(old, "", "  ") // nolint:gosec
-		newPretty, _ := json.MarshalIndent(new, "", "  ") // nolint:gosec
-
-		if isBeforeJSON && isAfterJSON {
-			diff := difflib.UnifiedDiff{
-				A:       difflib.SplitLines(string(oldPretty)),
-				B:       difflib.SplitLines(string(newPretty)),
-				Context: 5,
-			}
-			diffText, _ := difflib.GetUnifiedDiffString(diff) // nolint: gosec
-
-			printDiffAttribute(*a.Key, diffText, indentLength)
-		} else if isBeforeBase64 && isAfterBase64 && isASCII(oldString) && isASCII(newString) {
-			diff := difflib.UnifiedDiff{
-				A:       difflib.SplitLines(string(oldString)),
-				B:       difflib.SplitLines(string(newString)),
-				Context: 5,
-			}
-			diffText, _ := difflib.GetUnifiedDiffString(diff) // nolint: gosec
-
-			printDiffAttribute(*a.Key, diffText, indentLength)
+		beforePolicy, isBeforePolicy := parseIAMPolicy(before.Text)
+		afterPolicy, isAfterPolicy := parseIAMPolicy(after.Text)
+
+		if before.Format == "json" && after.Format == "json" && isBeforePolicy && isAfterPolicy {
+			printPolicyAttribute(w, *a.Key, beforePolicy, afterPolicy, label, indentLength)
+		} else if before.Format == "json" && after.Format == "json" {
+			printDiffAttribute(w, *a.Key, unifiedDiff(prettyJSON(before.Text), prettyJSON(after.Text)), label, indentLength)
+		} else if before.Format == "mime-multipart" && after.Format == "mime-multipart" {
+			printDiffAttribute(w, *a.Key, diffMIMEMessages(before.Text, after.Text), label, indentLength)
+		} else if before.Format == "yaml" && after.Format == "yaml" {
+			printDiffAttribute(w, *a.Key, unifiedDiff(normaliseYAML(before.Text), normaliseYAML(after.Text)), label, indentLength)
+		} else if len(before.Encodings) > 0 && len(after.Encodings) > 0 {
+			printDiffAttribute(w, *a.Key, unifiedDiff(before.Text, after.Text), label, indentLength)
 		} else {
-			printComplexAttribute(*a.Key, *a.Before, *a.After, false, a.NewResource, indentLength)
+			printComplexAttribute(w, *a.Key, *a.Before, *a.After, false, false, a.NewResource, indentLength)
 		}
 	}
 }
 
-func printComputedAttribute(key, value string, maxKeyLength int, printer func(a ...interface{}) string) {
+func unifiedDiff(before, after string) string {
+	diff := difflib.UnifiedDiff{
+		A:       difflib.SplitLines(before),
+		B:       difflib.SplitLines(after),
+		Context: diffContext(),
+	}
+	diffText, _ := difflib.GetUnifiedDiffString(diff) // nolint: gosec
+
+	return diffText
+}
+
+func prettyJSON(value string) string {
+	var j interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	decoder.Decode(&j) // nolint:gosec
+
+	pretty := marshalIndent(j, "", "  ")
+
+	return pretty
+}
+
+// marshalIndent is like json.MarshalIndent but does not escape HTML characters
+// so that values such as `<computed>` are printed as-is.
+func marshalIndent(v interface{}, prefix, indent string) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(prefix, indent)
+	encoder.Encode(v) // nolint:gosec
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func printComputedAttribute(w io.Writer, key, value string, maxKeyLength int, printer func(a ...interface{}) string) {
 	printModifier := fmt.Sprintf("%%s%%-%ds %%s\n", maxKeyLength)
 
-	fmt.Printf(printModifier, attributeIndentation, fmt.Sprintf("%s:", key), printer(value))
+	fmt.Fprintf(w, printModifier, attributeIndentation, fmt.Sprintf("%s:", key), printer(value))
 }
 
-func printSimpleAttribute(key, value string, maxKeyLength int, printer func(a ...interface{}) string) {
-	printModifier := fmt.Sprintf("%%s%%-%ds \"%%s\"\n", maxKeyLength)
+func printSimpleAttribute(w io.Writer, key, value string, maxKeyLength int, printer func(a ...interface{}) string) {
+	printModifier := fmt.Sprintf("%%s%%-%ds \"%%s\"%%s\n", maxKeyLength)
+
+	formattedValue, label := formatValue(value, maxKeyLength)
+	if label != "" {
+		label = " " + label
+	}
 
-	formattedValue := formatValue(value, maxKeyLength)
+	// 4 (attribute padding) + 1 (key/value space separation) + 1 (opening quote for value ")
+	valueColumn := maxKeyLength + 4 + 1 + 1
+	formattedValue = wrapValue(formattedValue, valueColumn, valueColumn)
 
-	fmt.Printf(printModifier, attributeIndentation, fmt.Sprintf("%s:", key), printer(formattedValue))
+	fmt.Fprintf(w, printModifier, attributeIndentation, fmt.Sprintf("%s:", key), printer(formattedValue), label)
 }
 
-func printComplexAttribute(key, before, after string, computed, newResource bool, maxKeyLength int) {
-	var afterModifier, formattedAfterValue string
+func printComplexAttribute(w io.Writer, key, before, after string, computed, changed, newResource bool, maxKeyLength int) {
+	// Values are indented past the "before " label in accessible mode
+	valueIndent := maxKeyLength
+	if options.Accessible {
+		valueIndent += len("before ")
+	}
+
+	var afterModifier, formattedAfterValue, label string
 	if computed {
 		afterModifier = "%s"
 		formattedAfterValue = after
 	} else {
 		afterModifier = "\"%s\""
-		formattedAfterValue = formatValue(after, maxKeyLength)
+		formattedAfterValue, label = formatValue(after, valueIndent)
+	}
+
+	var beforeModifier, formattedBeforeValue, beforeLabel string
+	if before == sensitiveValue {
+		// Sensitive values are shown the same way as computed ones
+		beforeModifier = "%s"
+		formattedBeforeValue = before
+	} else {
+		beforeModifier = "\"%s\""
+		formattedBeforeValue, beforeLabel = formatValue(before, valueIndent)
+	}
+
+	if label == "" {
+		label = beforeLabel
+	}
+
+	// 4 (attribute padding) + 1 (key/value space separation) + 1 (opening quote for value ")
+	valueColumn := valueIndent + 4 + 1 + 1
+	formattedBeforeValue = wrapValue(formattedBeforeValue, valueColumn, valueColumn)
+
+	// Width of the text between the before and after values
+	separatorWidth := len(`" => `)
+	if options.Accessible {
+		separatorWidth = len(`", after `)
 	}
 
-	formattedBeforeValue := formatValue(before, maxKeyLength)
+	if !computed {
+		// The after value starts on the last line of the before value, past
+		// the separator and its own opening quote.
+		afterColumn := lastLineWidth(formattedBeforeValue) + separatorWidth + 1
+		if !strings.Contains(formattedBeforeValue, "\n") {
+			afterColumn += valueColumn
+		}
+		formattedAfterValue = wrapValue(formattedAfterValue, afterColumn, valueColumn)
+	}
 
-	printModifier := fmt.Sprintf("%%s%%-%ds \"%%s\" => %s %%s\n", maxKeyLength, afterModifier)
+	printModifier := fmt.Sprintf("%%s%%-%ds %s => %s %%s\n", maxKeyLength, beforeModifier, afterModifier)
+	if options.Accessible {
+		printModifier = fmt.Sprintf("%%s%%-%ds before %s, after %s %%s\n", maxKeyLength, beforeModifier, afterModifier)
+	}
 
 	resourceText := ""
-	if newResource {
-		resourceText = yellowSprintf("(forces new resource)")
+	// Accessible mode already spells out replacements before the key
+	if newResource && !options.Accessible {
+		resourceText = theme.Warning.Sprint("(forces new resource)")
+	}
+
+	if changed {
+		resourceText = strings.TrimSpace(fmt.Sprintf("%s %s", theme.Warning.Sprint("(attribute changed)"), resourceText))
+	}
+
+	if label != "" {
+		resourceText = strings.TrimSpace(fmt.Sprintf("%s %s", label, resourceText))
 	}
 
-	fmt.Printf(printModifier, attributeIndentation, fmt.Sprintf("%s:", key), redSprintf(formattedBeforeValue), greenSprintf(formattedAfterValue), resourceText)
+	fmt.Fprintf(w, printModifier, attributeIndentation, fmt.Sprintf("%s:", key), theme.Removed.Sprint(formattedBeforeValue), theme.Added.Sprint(formattedAfterValue), resourceText)
 }
 
-func printDiffAttribute(key, diff string, maxKeyLength int) {
+func printDiffAttribute(w io.Writer, key, diff, label string, maxKeyLength int) {
 	printModifier := fmt.Sprintf("%%s%%-%ds ", maxKeyLength)
 
-	fmt.Printf(printModifier, attributeIndentation, fmt.Sprintf("%s:", key))
+	fmt.Fprintf(w, printModifier, attributeIndentation, fmt.Sprintf("%s:", key))
 
 	// 4 (attribute padding) + 1 (key/value space separation)
 	diffIdentLength := maxKeyLength + 4 + 1
@@ -217,6 +428,12 @@ func printDiffAttribute(key, diff string, maxKeyLength int) {
 
 	var padding string
 	printedFirstLine := true
+
+	// The label takes the place of the first diff line
+	if label != "" {
+		fmt.Fprintln(w, label)
+		printedFirstLine = false
+	}
 	lines := strings.Split(diff, "\n")
 	for i, l := range lines {
 		// Skip diff control lines (@@ -132,8 +134,8 @@)
@@ -232,20 +449,25 @@ func printDiffAttribute(key, diff string, maxKeyLength int) {
 		}
 
 		if len(l) > 0 && l[0] == '+' {
-			fmt.Printf("%s%s", padding, greenSprintf(l))
+			fmt.Fprintf(w, "%s%s", padding, theme.Added.Sprint(l))
 		} else if len(l) > 0 && l[0] == '-' {
-			fmt.Printf("%s%s", padding, redSprintf(l))
+			fmt.Fprintf(w, "%s%s", padding, theme.Removed.Sprint(l))
 		} else {
-			fmt.Print(padding, l)
+			fmt.Fprint(w, padding, l)
 		}
 
 		if i < len(lines) {
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 	}
 }
 
-func formatValue(value string, indentLength int) string {
+// formatValue decodes and formats the value for display. The second return
+// value is a label describing the decodings that were applied, if any.
+func formatValue(value string, indentLength int) (string, string) {
+	decoded := decodeValue(value)
+	value = redactSecrets(decoded.Text)
+
 	if json.Valid([]byte(value)) {
 		// Is JSON?
 		var j interface{}
@@ -257,85 +479,99 @@ func formatValue(value string, indentLength int) string {
 		// 4 (attribute padding) + 1 (key/value space separation) + 1 (opening quote for value ")
 		jsonIdentLegth := indentLength + 4 + 1 + 1
 
-		formattedValue, _ := json.MarshalIndent(j, strings.Repeat(" ", jsonIdentLegth), "  ") // nolint:gosec
+		formattedValue := marshalIndent(j, strings.Repeat(" ", jsonIdentLegth), "  ")
 
-		return string(formattedValue)
-	} else if strings.Contains(value, "\n") {
-		// Is multi-line value?
+		return formattedValue, decoded.label()
+	} else if formattedYAML, ok := formatYAML(value); ok {
+		// Is YAML? Leading comments (e.g. `#cloud-config`) are preserved.
 
 		// 4 (attribute padding) + 1 (key/value space separation) + 1 (opening quote for value ")
-		multiIdentLength := indentLength + 4 + 1 + 1
+		yamlIdentLength := indentLength + 4 + 1 + 1
 
-		newlineReplacement := fmt.Sprintf("\n%s", strings.Repeat(" ", multiIdentLength))
+		newlineReplacement := fmt.Sprintf("\n%s", strings.Repeat(" ", yamlIdentLength))
 
-		formattedValue := strings.Replace(value, "\n", newlineReplacement, -1)
+		return strings.Replace(formattedYAML, "\n", newlineReplacement, -1), decoded.label()
+	} else if strings.Contains(value, "\n") {
+		// Is multi-line value?
 
-		return formattedValue
-	} else if decodedString, err := base64.StdEncoding.DecodeString(value); err == nil && isASCII(decodedString) {
 		// 4 (attribute padding) + 1 (key/value space separation) + 1 (opening quote for value ")
 		multiIdentLength := indentLength + 4 + 1 + 1
 
 		newlineReplacement := fmt.Sprintf("\n%s", strings.Repeat(" ", multiIdentLength))
 
-		formattedValue := strings.Replace(string(decodedString), "\n", newlineReplacement, -1)
+		formattedValue := strings.Replace(value, "\n", newlineReplacement, -1)
 
-		return formattedValue
+		return formattedValue, decoded.label()
 	}
 
-	return value
+	return value, decoded.label()
 }
 
-func printMetadata(metadata *parser.Metadata) {
+func printMetadata(w io.Writer, metadata *parser.Metadata) {
 	if metadata != nil {
-		var add, change, destroy string
+		fmt.Fprintf(w, "Plan: %s.\n", formatChanges(*metadata))
+	}
+}
 
-		if metadata.Add > 0 {
-			add = color.New(color.FgGreen).SprintFunc()(fmt.Sprintf("%d to add", metadata.Add))
-		} else {
-			add = fmt.Sprintf("0 to add")
-		}
+// formatChanges returns the counts of a summary, e.g. `1 to add, 0 to change,
+// 0 to destroy`, colored when not zero.
+func formatChanges(metadata parser.Metadata) string {
+	var add, change, destroy string
 
-		if metadata.Change > 0 {
-			change = color.New(color.FgYellow).SprintFunc()(fmt.Sprintf("%d to change", metadata.Change))
-		} else {
-			change = fmt.Sprintf("0 to change")
-		}
+	if metadata.Add > 0 {
+		add = theme.Create.Sprint(fmt.Sprintf("%d to add", metadata.Add))
+	} else {
+		add = fmt.Sprintf("0 to add")
+	}
 
-		if metadata.Destroy > 0 {
-			destroy = color.New(color.FgRed).SprintFunc()(fmt.Sprintf("%d to destroy", metadata.Destroy))
-		} else {
-			destroy = fmt.Sprintf("0 to destroy")
-		}
+	if metadata.Change > 0 {
+		change = theme.Update.Sprint(fmt.Sprintf("%d to change", metadata.Change))
+	} else {
+		change = fmt.Sprintf("0 to change")
+	}
 
-		fmt.Printf("Plan: %s, %s, %s.\n", add, change, destroy)
+	if metadata.Destroy > 0 {
+		destroy = theme.Destroy.Sprint(fmt.Sprintf("%d to destroy", metadata.Destroy))
+	} else {
+		destroy = fmt.Sprintf("0 to destroy")
 	}
-}
 
-func isTerraformReference(s *string) bool {
-	terraformReference := regexp.MustCompile(`\$\{[a-zA-Z-_\.]+\}`)
-	return terraformReference.MatchString(*s)
+	changes := fmt.Sprintf("%s, %s, %s", add, change, destroy)
+
+	// Imported, forgotten and moved resources are only counted when there are
+	// any, like Terraform does
+	if metadata.Import > 0 {
+		changes = fmt.Sprintf("%s, %s", theme.Import.Sprint(fmt.Sprintf("%d to import", metadata.Import)), changes)
+	}
+
+	if metadata.Forget > 0 {
+		changes = fmt.Sprintf("%s, %s", changes, theme.Forget.Sprint(fmt.Sprintf("%d to forget", metadata.Forget)))
+	}
+
+	if metadata.Move > 0 {
+		changes = fmt.Sprintf("%s, %s", changes, theme.Move.Sprint(fmt.Sprintf("%d to move", metadata.Move)))
+	}
+
+	return changes
 }
 
-func isASCII(b []byte) bool {
-	s := string(b)
-	for i := 0; i < len(s); i++ {
-		if s[i] > unicode.MaxASCII {
-			return false
+// redactedAttribute returns a copy of the attribute with its values masked if
+// the attribute key indicates a secret.
+func redactedAttribute(a *parser.Attribute) *parser.Attribute {
+	redacted := *a
+
+	for _, v := range []**string{&redacted.Value, &redacted.Before, &redacted.After} {
+		if *v != nil {
+			masked := redactAttribute(*a.Key, **v)
+			*v = &masked
 		}
 	}
-	return true
+
+	return &redacted
 }
 
-func getTypeColor(c *string) *color.Color {
-	switch *c {
-	case "+":
-		return color.New(color.FgGreen)
-	case "-":
-		return color.New(color.FgRed)
-	case "~", "-/+":
-		return color.New(color.FgYellow)
-	case "<=":
-		return color.New(color.FgCyan)
-	}
-	return color.New(color.Reset)
+var terraformReferenceRE = regexp.MustCompile(`\$\{[a-zA-Z-_\.]+\}`)
+
+func isTerraformReference(s *string) bool {
+	return terraformReferenceRE.MatchString(*s)
 }
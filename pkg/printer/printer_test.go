@@ -2,18 +2,26 @@ package printer
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/fatih/color"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func init() {
+	// Placeholders of redacted secrets are the same from one run to the next
+	SetRedactionKey([]byte("scenery"))
+}
+
 func TestPrintPlan(t *testing.T) {
 	cases := []struct {
 		inputFile  string
@@ -23,6 +31,20 @@ func TestPrintPlan(t *testing.T) {
 		{"../../fixtures/rawPlans/base64CreateInput.txt", "../../fixtures/rawPlans/base64CreateOutput.txt"},
 		{"../../fixtures/rawPlans/multilineAttributeInput.txt", "../../fixtures/rawPlans/multilineAttributeOutput.txt"},
 		{"../../fixtures/rawPlans/floatInput.txt", "../../fixtures/rawPlans/floatOutput.txt"},
+		{"../../fixtures/rawPlans/iamPolicyInput.txt", "../../fixtures/rawPlans/iamPolicyOutput.txt"},
+		{"../../fixtures/rawPlans/yamlInput.txt", "../../fixtures/rawPlans/yamlOutput.txt"},
+		{"../../fixtures/rawPlans/gzipInput.txt", "../../fixtures/rawPlans/gzipOutput.txt"},
+		{"../../fixtures/rawPlans/mimeInput.txt", "../../fixtures/rawPlans/mimeOutput.txt"},
+		{"../../fixtures/rawPlans/escapedJSONInput.txt", "../../fixtures/rawPlans/escapedJSONOutput.txt"},
+		{"../../fixtures/rawPlans/secretsInput.txt", "../../fixtures/rawPlans/secretsOutput.txt"},
+		{"../../fixtures/rawPlans/summaryMismatchInput.txt", "../../fixtures/rawPlans/summaryMismatchOutput.txt"},
+		{"../../fixtures/rawPlans/destroyInput.txt", "../../fixtures/rawPlans/destroyOutput.txt"},
+		{"../../fixtures/rawPlans/driftInput.txt", "../../fixtures/rawPlans/driftOutput.txt"},
+		{"../../fixtures/rawPlans/driftRefreshOnlyInput.txt", "../../fixtures/rawPlans/driftRefreshOnlyOutput.txt"},
+		{"../../fixtures/rawPlans/movedImportedForgottenInput.txt", "../../fixtures/rawPlans/movedImportedForgottenOutput.txt"},
+		{"../../fixtures/rawPlans/modernInput.txt", "../../fixtures/rawPlans/modernOutput.txt"},
+		{"../../fixtures/rawPlans/modernIAMPolicyInput.txt", "../../fixtures/rawPlans/modernIAMPolicyOutput.txt"},
+		{"../../fixtures/rawPlans/streamValuesInput.txt", "../../fixtures/rawPlans/streamValuesOutput.txt"},
 	}
 
 	for _, tc := range cases {
@@ -43,6 +65,341 @@ func TestPrintPlan(t *testing.T) {
 	}
 }
 
+func TestStream(t *testing.T) {
+	cases := []struct {
+		inputFile  string
+		outputFile string
+	}{
+		{"../../fixtures/rawPlans/iamPolicyInput.txt", "../../fixtures/rawPlans/iamPolicyOutput.txt"},
+		{"../../fixtures/rawPlans/mimeInput.txt", "../../fixtures/rawPlans/mimeOutput.txt"},
+		{"../../fixtures/rawPlans/secretsInput.txt", "../../fixtures/rawPlans/secretsOutput.txt"},
+		{"../../fixtures/rawPlans/summaryMismatchInput.txt", "../../fixtures/rawPlans/summaryMismatchOutput.txt"},
+		{"../../fixtures/rawPlans/destroyInput.txt", "../../fixtures/rawPlans/destroyOutput.txt"},
+		{"../../fixtures/rawPlans/driftInput.txt", "../../fixtures/rawPlans/driftOutput.txt"},
+		{"../../fixtures/rawPlans/driftRefreshOnlyInput.txt", "../../fixtures/rawPlans/driftRefreshOnlyOutput.txt"},
+		{"../../fixtures/rawPlans/movedImportedForgottenInput.txt", "../../fixtures/rawPlans/movedImportedForgottenOutput.txt"},
+		{"../../fixtures/rawPlans/modernInput.txt", "../../fixtures/rawPlans/modernOutput.txt"},
+		{"../../fixtures/rawPlans/modernIAMPolicyInput.txt", "../../fixtures/rawPlans/modernIAMPolicyOutput.txt"},
+		{"../../fixtures/rawPlans/streamValuesInput.txt", "../../fixtures/rawPlans/streamValuesOutput.txt"},
+	}
+
+	for _, tc := range cases {
+		input, err := os.Open(tc.inputFile)
+		assert.NoError(t, err)
+
+		expected, err := ioutil.ReadFile(tc.outputFile)
+		assert.NoError(t, err)
+
+		var output bytes.Buffer
+		stream := NewStream(&output)
+
+		chunks := parser.NewChunkReader(input)
+		for {
+			chunk, err := chunks.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+
+			plan, err := parser.ParseChunk(chunk, chunks.First(), chunks.Last())
+			assert.NoError(t, err)
+
+			stream.Print(plan)
+		}
+		stream.Close()
+		input.Close() // nolint: errcheck
+
+		assert.Equal(t, string(expected), output.String())
+	}
+}
+
+func TestStreamUnparsedChunk(t *testing.T) {
+	input := "  + aws_eip.a\n      id: <computed>\n\n  + aws_eip.b\n      ??? id\n\nPlan: 2 to add, 0 to change, 0 to destroy.\n"
+
+	var output bytes.Buffer
+	stream := NewStream(&output)
+
+	chunks := parser.NewChunkReader(strings.NewReader(input))
+	for {
+		chunk, err := chunks.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		plan, err := parser.ParseChunk(chunk, chunks.First(), chunks.Last())
+		if err != nil || plan == nil {
+			stream.PrintUnparsed(chunk)
+			continue
+		}
+
+		stream.Print(plan)
+	}
+	stream.Close()
+
+	expected := "+ aws_eip.a\n    id: <computed>\n\n" +
+		"Warning: the following lines could not be parsed and are printed as-is:\n\n" +
+		"  + aws_eip.b\n      ??? id\n\nPlan: 2 to add, 0 to change, 0 to destroy.\n" +
+		"(end of the lines that could not be parsed)\n\n" +
+		"Warning: 1 part of the plan could not be parsed, the summary only counts the resources shown.\n"
+
+	assert.Equal(t, expected, output.String())
+}
+
+func TestStreamDestroyBanner(t *testing.T) {
+	input := "  - aws_s3_bucket.logs\n\nPlan: 0 to add, 0 to change, 1 to destroy.\n"
+
+	for _, prompt := range []bool{false, true} {
+		plan, err := parser.Parse(input)
+		assert.NoError(t, err)
+		plan.Destroy = prompt
+
+		var output bytes.Buffer
+		stream := NewStream(&output)
+		stream.Print(plan)
+		stream.Close()
+
+		// Only destroy runs get a banner, not every plan destroying resources
+		assert.Equal(t, prompt, strings.Contains(output.String(), "DESTROY:"))
+	}
+}
+
+func TestPrintPlanWithOptions(t *testing.T) {
+	defer SetOptions(Options{})
+
+	cases := []struct {
+		inputFile  string
+		outputFile string
+		options    Options
+	}{
+		{"../../fixtures/rawPlans/collapseInput.txt", "../../fixtures/rawPlans/collapseHideComputedOutput.txt", Options{HideComputed: true}},
+		{"../../fixtures/rawPlans/collapseInput.txt", "../../fixtures/rawPlans/collapseOnlyChangedOutput.txt", Options{OnlyChanged: true}},
+		{"../../fixtures/rawPlans/collapseInput.txt", "../../fixtures/rawPlans/collapseCompactOutput.txt", Options{Compact: true}},
+		{"../../fixtures/rawPlans/wrapInput.txt", "../../fixtures/rawPlans/wrapOutput.txt", Options{Width: 80}},
+		{"../../fixtures/rawPlans/wrapInput.txt", "../../fixtures/rawPlans/wrapNoTruncateOutput.txt", Options{Width: 80, NoTruncate: true}},
+		{"../../fixtures/rawPlans/accessibleInput.txt", "../../fixtures/rawPlans/accessibleOutput.txt", Options{Accessible: true}},
+	}
+
+	for _, tc := range cases {
+		input, err := ioutil.ReadFile(tc.inputFile)
+		assert.NoError(t, err)
+
+		expected, err := ioutil.ReadFile(tc.outputFile)
+		assert.NoError(t, err)
+
+		plan, err := parser.Parse(string(input))
+		assert.NoError(t, err)
+
+		SetOptions(tc.options)
+		output := captureOutput(func() {
+			PrettyPrint(plan)
+		})
+
+		assert.Equal(t, string(expected), output)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	cases := []struct {
+		value             string
+		expectedText      string
+		expectedEncodings []string
+		expectedFormat    string
+	}{
+		{"plain text", "plain text", nil, ""},
+		{"e32a558d4e72c9157173e8b0f9e64f4e", "e32a558d4e72c9157173e8b0f9e64f4e", nil, ""},
+		{"eyJrZXkiOiAidmFsdWU_In0=", "{\"key\": \"value?\"}", []string{"base64url"}, "json"},
+		{"H4sIAAAAAAACAytJLS7hAgDGNbk7BQAAAA==", "test\n", []string{"base64", "gzip"}, ""},
+		{"%7B%22key%22%3A%20%22value%22%7D", `{"key": "value"}`, []string{"url"}, "json"},
+		{`{\"key\": \"value\"}`, `{"key": "value"}`, []string{"json-string"}, "json"},
+		{`"\"{\\\"key\\\": 1}\""`, `{"key": 1}`, []string{"json-string", "json-string"}, "json"},
+		{`{"cmd": "echo \"hi\""}`, `{"cmd": "echo \"hi\""}`, nil, "json"},
+		{`{"url":"a%2Fb"}`, `{"url":"a%2Fb"}`, nil, "json"},
+		{`{\"url\":\"a%2Fb\"}`, `{"url":"a%2Fb"}`, []string{"json-string"}, "json"},
+	}
+
+	for _, tc := range cases {
+		decoded := decodeValue(tc.value)
+
+		assert.Equal(t, tc.expectedText, decoded.Text)
+		assert.Equal(t, tc.expectedEncodings, decoded.Encodings)
+		assert.Equal(t, tc.expectedFormat, decoded.Format)
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	defer SetRedactionKey([]byte("scenery"))
+
+	assert.Equal(t, placeholder("hunter2"), placeholder("hunter2"))
+	assert.NotEqual(t, placeholder("hunter2"), placeholder("hunter3"))
+	assert.Regexp(t, `^<redacted:[0-9a-f]{8}>$`, placeholder("hunter2"))
+
+	// Placeholders depend on the key, which is random unless set
+	before := placeholder("hunter2")
+	SetRedactionKey([]byte("another key"))
+	assert.NotEqual(t, before, placeholder("hunter2"))
+}
+
+func TestFormatYAML(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+		ok       bool
+	}{
+		{"expands flow style documents", "{app: scenery, tier: [web, worker]}", "app: scenery\ntier:\n- web\n- worker", true},
+		{"normalises block style documents", "replicaCount:   2\nimage:\n    repository: nginx\n    tag: '1.16'\n", "replicaCount: 2\nimage:\n  repository: nginx\n  tag: \"1.16\"", true},
+		{"keeps the leading comments", "#cloud-config\npackages:\n    - nginx\n", "#cloud-config\npackages:\n- nginx", true},
+		{"keeps streams of several documents", "a: 1\n---\nb: 2\n", "", false},
+		{"ignores plain strings", "hello: world", "", false},
+		{"ignores multi-line text", "line one\nline two", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, ok := formatYAML(tc.value)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, formatted)
+		})
+	}
+}
+
+func TestIsIAMWildcard(t *testing.T) {
+	cases := []struct {
+		field    string
+		value    string
+		expected bool
+	}{
+		{"action", "*", true},
+		{"action", "s3:*", true},
+		{"action", "s3:Get*", false},
+		{"resource", "*", true},
+		{"resource", "arn:aws:s3:::bucket/*", false},
+		{"not_resource", "arn:aws:s3:::*", false},
+		{"principal", "*", true},
+		{"principal", "AWS:*", true},
+		{"principal", "AWS:arn:aws:iam::123456789012:root", false},
+		{"condition", "StringLike s3:prefix = *", false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, isIAMWildcard(tc.field, tc.value), "%s %s", tc.field, tc.value)
+	}
+}
+
+func TestDiffMIMEMessages(t *testing.T) {
+	message := "Content-Type: multipart/mixed; boundary=\"b\"\n\n--b\nContent-Type: text/x-shellscript\n\n%s\n--b\n%s\n\nbody\n--b--\n"
+
+	before := fmt.Sprintf(message, "echo a", "Content-Type: text/plain")
+	after := fmt.Sprintf(message, "echo b", "Content-Type: text/plain")
+	assert.Contains(t, diffMIMEMessages(before, after), "~ part text/x-shellscript #1")
+
+	// Malformed parts fall back to a plain diff rather than dropping parts
+	malformed := fmt.Sprintf(message, "echo b", "malformed header")
+	assert.Equal(t, unifiedDiff(before, malformed), diffMIMEMessages(before, malformed))
+}
+
+func TestDiffContext(t *testing.T) {
+	defer SetOptions(Options{})
+
+	before := "a\nb\nc\nd\n"
+	after := "a\nb\nC\nd\n"
+
+	SetOptions(Options{})
+	assert.Contains(t, unifiedDiff(before, after), "b")
+
+	zero := 0
+	SetOptions(Options{DiffContext: &zero})
+	assert.NotContains(t, unifiedDiff(before, after), "b")
+	assert.Contains(t, unifiedDiff(before, after), "C")
+}
+
+func TestParseTheme(t *testing.T) {
+	cases := []struct {
+		spec           string
+		expectedName   string
+		expectedCreate Style
+		expectedError  bool
+	}{
+		{"default", "default", Style{color.FgGreen}, false},
+		{"", "default", Style{color.FgGreen}, false},
+		{"monochrome", "monochrome", Style{color.Bold}, false},
+		{"deuteranopia", "deuteranopia", Style{38, 5, 33}, false},
+		{"high-contrast,create=bright-blue+underline", "high-contrast", Style{color.FgHiBlue, color.Underline}, false},
+		{"create=#56b4e9+bold", "default", Style{38, 2, 0x56, 0xb4, 0xe9, color.Bold}, false},
+		{"default,create=none", "default", nil, false},
+		{"solarized", "", nil, true},
+		{"default,create", "", nil, true},
+		{"default,created=red", "", nil, true},
+		{"default,create=256", "", nil, true},
+	}
+
+	for _, tc := range cases {
+		theme, err := ParseTheme(tc.spec)
+		if tc.expectedError {
+			assert.Error(t, err, tc.spec)
+			continue
+		}
+
+		assert.NoError(t, err, tc.spec)
+		assert.Equal(t, tc.expectedName, theme.Name, tc.spec)
+		assert.Equal(t, tc.expectedCreate, theme.Create, tc.spec)
+	}
+}
+
+func TestThemeStyles(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	assert.Equal(t, "\x1b[32m+\x1b[0m", themes["default"].Create.Sprint("+"))
+	assert.Equal(t, "\x1b[38;5;208m-\x1b[0m", themes["deuteranopia"].Destroy.Sprint("-"))
+	assert.Equal(t, "\x1b[1m~\x1b[0m", themes["monochrome"].Update.Sprint("~"))
+	assert.Equal(t, "<=", Style(nil).Sprint("<="))
+}
+
+func TestLinkResourceType(t *testing.T) {
+	defer SetOptions(Options{})
+
+	cases := []struct {
+		address   string
+		templates map[string]string
+		expected  string
+	}{
+		{
+			"aws_security_group_rule.ingress",
+			nil,
+			"\x1b]8;;https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/security_group_rule\x1b\\aws_security_group_rule\x1b]8;;\x1b\\.ingress",
+		},
+		{
+			"module.vpc.data.aws_ami.ubuntu",
+			nil,
+			"module.vpc.data.\x1b]8;;https://registry.terraform.io/providers/hashicorp/aws/latest/docs/data-sources/ami\x1b\\aws_ami\x1b]8;;\x1b\\.ubuntu",
+		},
+		{
+			"acme_widget.main[0]",
+			map[string]string{"acme": "https://docs.example.com/{kind}/{type}"},
+			"\x1b]8;;https://docs.example.com/resources/acme_widget\x1b\\acme_widget\x1b]8;;\x1b\\.main[0]",
+		},
+		{
+			"google_compute_instance.web",
+			map[string]string{"*": "https://docs.example.com/{provider}/{name}"},
+			"\x1b]8;;https://docs.example.com/google/compute_instance\x1b\\google_compute_instance\x1b]8;;\x1b\\.web",
+		},
+		{"module.app", nil, "module.app"},
+	}
+
+	for _, tc := range cases {
+		SetOptions(Options{Hyperlinks: true, DocsURLTemplates: tc.templates})
+
+		assert.Equal(t, tc.expected, linkResourceType(tc.address))
+	}
+
+	SetOptions(Options{})
+	assert.Equal(t, "aws_instance.web", linkResourceType("aws_instance.web"))
+}
+
 // https://gist.github.com/hauxe/e935a7f9012bf2649710cf75af323dbf#file-output_capturing_full-go
 func captureOutput(f func()) string {
 	reader, writer, err := os.Pipe()
@@ -73,3 +430,67 @@ func captureOutput(f func()) string {
 	writer.Close()
 	return <-out
 }
+
+func TestFprintTotal(t *testing.T) {
+	replace := "-/+"
+	plans := []*parser.Plan{
+		{Metadata: &parser.Metadata{Add: 2, Change: 1}},
+		{Resources: []*parser.Resource{{Header: &parser.Header{Change: &replace}}}},
+		{NoChanges: true},
+	}
+
+	var output bytes.Buffer
+	FprintTotal(&output, plans, 0, 0)
+	assert.Equal(t, "Total: 3 to add, 1 to change, 1 to destroy across 3 plans.\n", output.String())
+
+	output.Reset()
+	FprintTotal(&output, plans[:1], 0, 1)
+	assert.Equal(t, "Total: 2 to add, 1 to change, 0 to destroy across 2 plans (1 failed to parse).\n", output.String())
+
+	output.Reset()
+	FprintTotal(&output, plans[:1], 2, 1)
+	assert.Equal(t, "Total: 2 to add, 1 to change, 0 to destroy across 4 plans (2 failed to read, 1 failed to parse).\n", output.String())
+}
+
+func TestPrintApply(t *testing.T) {
+	cases := []struct {
+		inputFile  string
+		outputFile string
+	}{
+		{"../../fixtures/rawPlans/applyInput.txt", "../../fixtures/rawPlans/applyOutput.txt"},
+		{"../../fixtures/rawPlans/applyBracketsInput.txt", "../../fixtures/rawPlans/applyBracketsOutput.txt"},
+		{"../../fixtures/rawPlans/applyDestroyInput.txt", "../../fixtures/rawPlans/applyDestroyOutput.txt"},
+	}
+
+	for _, tc := range cases {
+		input, err := ioutil.ReadFile(tc.inputFile)
+		assert.NoError(t, err)
+
+		expected, err := ioutil.ReadFile(tc.outputFile)
+		assert.NoError(t, err)
+
+		apply, err := parser.ParseApply(string(input))
+		assert.NoError(t, err)
+
+		var output bytes.Buffer
+		FprintApply(&output, apply)
+
+		assert.Equal(t, string(expected), output.String())
+	}
+}
+
+func TestPrintState(t *testing.T) {
+	input, err := ioutil.ReadFile("../../fixtures/rawPlans/stateInput.txt")
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("../../fixtures/rawPlans/stateOutput.txt")
+	assert.NoError(t, err)
+
+	state, err := parser.ParseState(string(input))
+	assert.NoError(t, err)
+
+	var output bytes.Buffer
+	FprintState(&output, state)
+
+	assert.Equal(t, string(expected), output.String())
+}
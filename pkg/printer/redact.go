@@ -0,0 +1,122 @@
+package printer
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sensitiveValue is how Terraform displays values marked as sensitive.
+const sensitiveValue = "<sensitive>"
+
+// secretPattern matches a likely secret. If the pattern has a capture group
+// named "secret" only that group is replaced, otherwise the whole match is.
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?-----END ([A-Z0-9]+ )*PRIVATE KEY-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)(aws_secret_access_key|secret_access_key|secretaccesskey)(\\?["']?\s*[:=]\s*\\?["']?)(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"bearer-token", regexp.MustCompile(`(?i)(bearer\s+)(?P<secret>[A-Za-z0-9\-._~+/]{8,}=*)`)},
+	// "password": "hunter2" (JSON, possibly escaped)
+	{"json-secret", regexp.MustCompile(`(?i)(\\?"[a-z0-9_.-]*(password|passwd|secret|token|api_?key|private_?key)\\?"\s*:\s*\\?")(?P<secret>[^"\\]+)`)},
+	// password: hunter2 (YAML)
+	{"yaml-secret", regexp.MustCompile(`(?im)^(\s*-?\s*[a-z0-9_.-]*(password|passwd|secret|token|api_?key|private_?key)\s*:[ \t]+["']?)(?P<secret>[^"'\s#][^"'\n#]*?)(["']?[ \t]*)$`)},
+	// DB_PASSWORD=hunter2 (environment variables in scripts)
+	{"env-secret", regexp.MustCompile(`(?i)(\b[a-z0-9_]*(password|passwd|secret|token|api_?key)[a-z0-9_]*=["']?)(?P<secret>[^\s"'$]+)`)},
+}
+
+// secretKeyRE matches attribute keys whose values are likely secrets.
+var secretKeyRE = regexp.MustCompile(`(?i)(^|[._-])(password|passwd|secret|token|api_?key|private_?key|secret_?key)$`)
+
+// redactAttribute masks the whole value of attributes whose key indicates a
+// secret (e.g. `master_password`).
+func redactAttribute(key, value string) string {
+	if options.Reveal || value == "" || value == sensitiveValue || !secretKeyRE.MatchString(key) {
+		return value
+	}
+
+	return placeholder(value)
+}
+
+// redactSecrets replaces likely secrets in text with stable placeholders. Each
+// secret gets its own placeholder so that a changed secret still shows up as
+// changed in diffs without leaking its value.
+func redactSecrets(text string) string {
+	if options.Reveal {
+		return text
+	}
+
+	for _, p := range secretPatterns {
+		secretIndex := secretGroupIndex(p.re)
+
+		text = p.re.ReplaceAllStringFunc(text, func(match string) string {
+			if secretIndex < 0 {
+				return placeholder(match)
+			}
+
+			groups := p.re.FindStringSubmatchIndex(match)
+			if groups == nil {
+				return placeholder(match)
+			}
+
+			start, end := groups[2*secretIndex], groups[2*secretIndex+1]
+			if start < 0 || strings.HasPrefix(match[start:end], "<redacted:") {
+				return match
+			}
+
+			return match[:start] + placeholder(match[start:end]) + match[end:]
+		})
+	}
+
+	return text
+}
+
+// secretGroupIndex returns the index of the "secret" capture group or -1 if the
+// pattern does not have one.
+func secretGroupIndex(re *regexp.Regexp) int {
+	for i, name := range re.SubexpNames() {
+		if name == "secret" {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// redactionKey is the key placeholders are derived from. It is random unless
+// set with SetRedactionKey so that placeholders, which are short, cannot be
+// matched with guessed secrets.
+var redactionKey = newRedactionKey()
+
+func newRedactionKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key) // nolint: gosec, errcheck
+
+	return key
+}
+
+// SetRedactionKey sets the key placeholders are derived from, e.g. to get the
+// same placeholders across runs.
+func SetRedactionKey(key []byte) {
+	redactionKey = key
+}
+
+// placeholder returns the placeholder of a secret, e.g. `<redacted:5f2b9c1e>`,
+// a keyed hash of the secret so that the same secret gets the same
+// placeholder wherever it appears.
+func placeholder(secret string) string {
+	mac := hmac.New(sha256.New, redactionKey)
+	mac.Write([]byte(secret)) // nolint: gosec, errcheck
+
+	return fmt.Sprintf("<redacted:%x>", mac.Sum(nil)[:4])
+}
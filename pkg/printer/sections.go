@@ -0,0 +1,63 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// FprintSection prints the heading of the section of a plan when printing
+// several plans, e.g. `==> envs/production <==`.
+func FprintSection(w io.Writer, label string) {
+	fmt.Fprintln(w, theme.Heading.Sprint(fmt.Sprintf("==> %s <==", label)))
+	fmt.Fprintln(w)
+}
+
+// FprintTotal prints the summary of several plans, the changes of each plan
+// being counted from its summary, or from its resources if it has none, along
+// with the changes made outside of Terraform. Plans that could not be read or
+// parsed are only counted.
+func FprintTotal(w io.Writer, plans []*parser.Plan, unread, unparsed int) {
+	var total parser.Metadata
+	var drift parser.DriftSummary
+	for _, p := range plans {
+		d := p.CountDrift()
+		drift.Changed += d.Changed
+		drift.Deleted += d.Deleted
+
+		m := p.CountChanges()
+		if p.Metadata != nil {
+			m = *p.Metadata
+		}
+
+		total.Add += m.Add
+		total.Change += m.Change
+		total.Destroy += m.Destroy
+		total.Import += m.Import
+		total.Forget += m.Forget
+		total.Move += m.Move
+	}
+
+	count := len(plans) + unread + unparsed
+	noun := "plans"
+	if count == 1 {
+		noun = "plan"
+	}
+
+	fmt.Fprintf(w, "Total: %s across %d %s", formatChanges(total), count, noun)
+	var failures []string
+	if unread > 0 {
+		failures = append(failures, fmt.Sprintf("%d failed to read", unread))
+	}
+	if unparsed > 0 {
+		failures = append(failures, fmt.Sprintf("%d failed to parse", unparsed))
+	}
+	if len(failures) > 0 {
+		fmt.Fprint(w, theme.Warning.Sprint(fmt.Sprintf(" (%s)", strings.Join(failures, ", "))))
+	}
+	fmt.Fprintln(w, ".")
+
+	printDriftSummary(w, drift)
+}
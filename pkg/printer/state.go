@@ -0,0 +1,22 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// FprintState prints the resources of the output of `terraform state show`
+// or `terraform show`, decoding and formatting their values like the values
+// of plans.
+func FprintState(w io.Writer, s *parser.State) {
+	for i, r := range s.Resources {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintln(w, theme.Heading.Sprint(fmt.Sprintf("# %s", linkResourceType(r.Address))))
+		printAttributes(w, r.Attributes, "", nil)
+	}
+}
@@ -0,0 +1,159 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+)
+
+// Stream prints a plan parsed in several chunks (see parser.ChunkReader) as
+// the chunks are parsed, the summary being printed once every chunk was.
+type Stream struct {
+	w io.Writer
+
+	counted   parser.Metadata
+	metadata  *parser.Metadata
+	resources bool
+	noChanges bool
+
+	// destroyed counts the resources destroyed by type, and destroyPrompt
+	// records whether Terraform asked for confirmation to destroy all
+	// resources, to warn about destroy runs.
+	destroyed     map[string]int
+	destroyPrompt bool
+
+	drift parser.DriftSummary
+
+	// unparsed counts the chunks that could not be parsed, which the summary
+	// of the plan does not account for.
+	unparsed int
+}
+
+// NewStream returns a Stream printing to w.
+func NewStream(w io.Writer) *Stream {
+	return &Stream{w: w, destroyed: map[string]int{}}
+}
+
+// Print prints the warnings and resources of a chunk of the plan.
+func (s *Stream) Print(p *parser.Plan) {
+	if p.Warnings != nil {
+		for _, warning := range *p.Warnings {
+			fmt.Fprint(s.w, theme.Warning.Sprint(warning))
+			if !strings.HasSuffix(warning, "\n") {
+				fmt.Fprintln(s.w)
+			}
+		}
+		fmt.Fprintln(s.w)
+	}
+
+	if len(p.Drift) > 0 {
+		printDrift(s.w, p.Drift)
+
+		if len(p.Resources) > 0 {
+			fmt.Fprintln(s.w, PlannedHeading())
+			fmt.Fprintln(s.w)
+		}
+
+		d := p.CountDrift()
+		s.drift.Changed += d.Changed
+		s.drift.Deleted += d.Deleted
+	}
+
+	if p.NoChanges {
+		fmt.Fprintln(s.w, "No changes.")
+		s.noChanges = true
+		return
+	}
+
+	for _, r := range p.Resources {
+		printResource(s.w, r)
+		s.resources = true
+
+		if *r.Header.Change == "-" {
+			if t, _, _ := resourceType(*r.Header.Name); t != "" {
+				s.destroyed[t]++
+			}
+		}
+	}
+
+	s.destroyPrompt = s.destroyPrompt || p.Destroy
+
+	counted := p.CountChanges()
+	s.counted.Add += counted.Add
+	s.counted.Change += counted.Change
+	s.counted.Destroy += counted.Destroy
+	s.counted.Import += counted.Import
+	s.counted.Forget += counted.Forget
+	s.counted.Move += counted.Move
+
+	if p.Metadata != nil {
+		metadata := *p.Metadata
+		s.metadata = &metadata
+	}
+}
+
+// PrintUnparsed prints a chunk of the plan that could not be parsed as-is,
+// between markers so that it is not mistaken for prettified resources.
+func (s *Stream) PrintUnparsed(chunk string) {
+	fmt.Fprintln(s.w, theme.Warning.Sprint("Warning: the following lines could not be parsed and are printed as-is:"))
+	fmt.Fprintln(s.w)
+	fmt.Fprint(s.w, strings.TrimRight(chunk, "\n"))
+	fmt.Fprintln(s.w)
+	fmt.Fprintln(s.w, theme.Warning.Sprint("(end of the lines that could not be parsed)"))
+	fmt.Fprintln(s.w)
+
+	s.unparsed++
+}
+
+// Close prints the summary of the plan and of the changes made outside of
+// Terraform along with a banner listing the types of the resources destroyed
+// by destroy runs and a warning if the summary does not match the resources
+// that were printed or if parts of the plan could not be parsed.
+func (s *Stream) Close() {
+	if s.noChanges {
+		printDriftSummary(s.w, s.drift)
+		return
+	}
+
+	if options.Compact && s.resources {
+		fmt.Fprintln(s.w)
+	}
+
+	// Moves are counted over every chunk since Terraform does not summarise
+	// them
+	if s.metadata != nil {
+		s.metadata.Move = s.counted.Move
+	}
+
+	printMetadata(s.w, s.metadata)
+	printDriftSummary(s.w, s.drift)
+
+	if s.destroyPrompt && len(s.destroyed) > 0 {
+		if s.metadata != nil {
+			fmt.Fprintln(s.w)
+		}
+		printDestroyBanner(s.w, s.destroyed)
+	}
+
+	// The summary cannot be checked against resources that were not parsed
+	if s.unparsed > 0 {
+		if s.metadata != nil {
+			fmt.Fprintln(s.w)
+		}
+		fmt.Fprintln(s.w, theme.Warning.Sprint(fmt.Sprintf("Warning: %d %s of the plan could not be parsed, the summary only counts the resources shown.", s.unparsed, pluralize("part", s.unparsed))))
+		return
+	}
+
+	if err := s.CheckSummary(); err != nil {
+		fmt.Fprintln(s.w)
+		fmt.Fprintln(s.w, theme.Warning.Sprint(fmt.Sprintf("Warning: %s.", err)))
+	}
+}
+
+// CheckSummary returns an error if the summary of the plan does not match the
+// resources that were printed (see parser.Plan.CheckSummary).
+func (s *Stream) CheckSummary() error {
+	return parser.CompareSummary(s.counted, s.metadata)
+}
@@ -0,0 +1,307 @@
+package printer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/fatih/color"
+)
+
+// Style is the list of SGR attributes (colors, bold, ...) text is printed
+// with.
+type Style []color.Attribute
+
+// Sprint formats the operands like fmt.Sprint and applies the style.
+func (s Style) Sprint(a ...interface{}) string {
+	if len(s) == 0 {
+		return fmt.Sprint(a...)
+	}
+
+	return color.New(s...).Sprint(a...)
+}
+
+// Theme defines the styles used for each kind of change.
+type Theme struct {
+	Name string
+
+	// Create, Destroy, Update and Read style resources (and their count in
+	// the plan summary) by action.
+	Create  Style
+	Destroy Style
+	Update  Style
+	Read    Style
+
+	// Added, Removed and Modified style new values, old values and changed
+	// parts of diffs and policy summaries.
+	Added    Style
+	Removed  Style
+	Modified Style
+
+	// Warning styles plan warnings and notes such as "(forces new resource)".
+	Warning Style
+
+	// Label styles notes about how a value was decoded.
+	Label Style
+
+	// Risky styles values that warrant attention, e.g. policy wildcards.
+	Risky Style
+
+	// Heading styles the headings of sections, e.g. of each plan when
+	// printing several.
+	Heading Style
+
+	// Drift styles resources changed outside of Terraform.
+	Drift Style
+
+	// Move, Import and Forget style resources moved, imported or removed from
+	// the state without being destroyed (and their count in the summary).
+	Move   Style
+	Import Style
+	Forget Style
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Name:     "default",
+		Create:   Style{color.FgGreen},
+		Destroy:  Style{color.FgRed},
+		Update:   Style{color.FgYellow},
+		Read:     Style{color.FgCyan},
+		Added:    Style{color.FgGreen},
+		Removed:  Style{color.FgRed},
+		Modified: Style{color.FgYellow},
+		Warning:  Style{color.FgYellow},
+		Label:    Style{color.FgCyan},
+		Risky:    Style{color.FgRed, color.Bold},
+		Heading:  Style{color.Bold},
+		Drift:    Style{color.FgMagenta},
+		Move:     Style{color.FgBlue},
+		Import:   Style{color.FgHiGreen},
+		Forget:   Style{color.FgHiBlack},
+	},
+	// Blue and orange remain distinguishable with red-green color blindness.
+	"deuteranopia": {
+		Name:     "deuteranopia",
+		Create:   color256(33),
+		Destroy:  color256(208),
+		Update:   color256(227),
+		Read:     color256(250),
+		Added:    color256(33),
+		Removed:  color256(208),
+		Modified: color256(227),
+		Warning:  color256(227),
+		Label:    color256(250),
+		Risky:    append(color256(208), color.Bold, color.Underline),
+		Heading:  Style{color.Bold},
+		Drift:    color256(141),
+		Move:     color256(183),
+		Import:   color256(117),
+		Forget:   color256(244),
+	},
+	"high-contrast": {
+		Name:     "high-contrast",
+		Create:   Style{color.FgHiGreen, color.Bold},
+		Destroy:  Style{color.FgHiRed, color.Bold},
+		Update:   Style{color.FgHiYellow, color.Bold},
+		Read:     Style{color.FgHiCyan, color.Bold},
+		Added:    Style{color.FgHiGreen, color.Bold},
+		Removed:  Style{color.FgHiRed, color.Bold},
+		Modified: Style{color.FgHiYellow, color.Bold},
+		Warning:  Style{color.FgHiYellow, color.Bold},
+		Label:    Style{color.FgHiCyan},
+		Risky:    Style{color.FgHiWhite, color.BgRed, color.Bold},
+		Heading:  Style{color.FgHiWhite, color.Bold},
+		Drift:    Style{color.FgHiMagenta, color.Bold},
+		Move:     Style{color.FgHiBlue, color.Bold},
+		Import:   Style{color.FgHiGreen, color.Bold, color.Underline},
+		Forget:   Style{color.FgHiWhite, color.Underline},
+	},
+	"monochrome": {
+		Name:     "monochrome",
+		Create:   Style{color.Bold},
+		Destroy:  Style{color.Underline},
+		Update:   Style{color.Bold},
+		Read:     Style{color.Faint},
+		Added:    Style{color.Bold},
+		Removed:  Style{color.Underline},
+		Modified: Style{color.Bold},
+		Warning:  Style{color.Bold},
+		Label:    Style{color.Faint},
+		Risky:    Style{color.Bold, color.Underline},
+		Heading:  Style{color.Bold},
+		Drift:    Style{color.Italic},
+		Move:     Style{color.Italic, color.Underline},
+		Import:   Style{color.Bold, color.Italic},
+		Forget:   Style{color.Faint, color.Underline},
+	},
+}
+
+var theme = themes["default"]
+
+// colorNames maps the color names accepted by ParseTheme to their attributes.
+var colorNames = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"bright-black":   color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+	"bold":           color.Bold,
+	"faint":          color.Faint,
+	"italic":         color.Italic,
+	"underline":      color.Underline,
+	"reverse":        color.ReverseVideo,
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ParseTheme returns the theme described by spec, which is the name of a
+// built-in theme optionally followed by overrides of some of its styles.
+// Styles combine color names, 256-color numbers, #rrggbb truecolor values and
+// attributes with "+".
+//
+// Example:
+//
+//	deuteranopia,create=#56b4e9,risky=208+bold
+func ParseTheme(spec string) (Theme, error) {
+	parts := strings.Split(spec, ",")
+
+	name := "default"
+	if first := strings.TrimSpace(parts[0]); !strings.Contains(first, "=") {
+		if first != "" {
+			name = first
+		}
+		parts = parts[1:]
+	}
+
+	t, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	styles := t.styles()
+	for _, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Theme{}, fmt.Errorf("invalid theme override %q, expected <style>=<value>", part)
+		}
+
+		style, ok := styles[strings.TrimSpace(kv[0])]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown theme style %q", kv[0])
+		}
+
+		s, err := parseStyle(kv[1])
+		if err != nil {
+			return Theme{}, err
+		}
+		*style = s
+	}
+
+	return t, nil
+}
+
+func (t *Theme) styles() map[string]*Style {
+	return map[string]*Style{
+		"create":   &t.Create,
+		"destroy":  &t.Destroy,
+		"update":   &t.Update,
+		"read":     &t.Read,
+		"added":    &t.Added,
+		"removed":  &t.Removed,
+		"modified": &t.Modified,
+		"warning":  &t.Warning,
+		"label":    &t.Label,
+		"risky":    &t.Risky,
+		"heading":  &t.Heading,
+		"drift":    &t.Drift,
+		"move":     &t.Move,
+		"import":   &t.Import,
+		"forget":   &t.Forget,
+	}
+}
+
+func parseStyle(value string) (Style, error) {
+	var s Style
+
+	for _, token := range strings.Split(strings.ToLower(strings.TrimSpace(value)), "+") {
+		if a, ok := colorNames[token]; ok {
+			s = append(s, a)
+			continue
+		}
+
+		if token == "none" {
+			continue
+		}
+
+		if strings.HasPrefix(token, "#") && len(token) == 7 {
+			rgb, err := strconv.ParseUint(token[1:], 16, 32)
+			if err == nil {
+				s = append(s, truecolor(int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff))...)
+				continue
+			}
+		}
+
+		if n, err := strconv.Atoi(token); err == nil && n >= 0 && n <= 255 {
+			s = append(s, color256(n)...)
+			continue
+		}
+
+		return nil, fmt.Errorf("invalid style %q", token)
+	}
+
+	return s, nil
+}
+
+// color256 returns the style for a color of the 256-color palette.
+func color256(n int) Style {
+	return Style{38, 5, color.Attribute(n)}
+}
+
+func truecolor(r, g, b int) Style {
+	return Style{38, 2, color.Attribute(r), color.Attribute(g), color.Attribute(b)}
+}
+
+// actionStyle returns the style of resources with the given change.
+func actionStyle(change string) Style {
+	switch change {
+	case "+":
+		return theme.Create
+	case "-":
+		return theme.Destroy
+	case "~", "-/+":
+		return theme.Update
+	case "<=":
+		return theme.Read
+	case parser.MoveChange:
+		return theme.Move
+	case parser.ImportChange:
+		return theme.Import
+	case parser.ForgetChange:
+		return theme.Forget
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxWrappedRows is the number of rows a single line of a value may be
+	// wrapped over before the rest of it is truncated.
+	maxWrappedRows = 10
+
+	// minWrapWidth is the narrowest space values are wrapped into. Values
+	// starting further right than that are printed as-is rather than being
+	// squeezed into a column of a few characters.
+	minWrapWidth = 20
+)
+
+// wrapValue soft-wraps every line of value so that it fits within
+// options.Width. The first line of value starts at firstColumn while the
+// following lines are expected to be indented to column, which is how
+// formatValue lays out multi-line values. Wrapped rows are indented to column
+// plus the indentation of the line they belong to.
+//
+// Lines too long to fit within maxWrappedRows rows are truncated unless
+// options.NoTruncate is set.
+func wrapValue(value string, firstColumn, column int) string {
+	if options.Width <= 0 {
+		return value
+	}
+
+	padding := strings.Repeat(" ", column)
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		start := firstColumn
+		if i > 0 {
+			if !strings.HasPrefix(line, padding) {
+				continue
+			}
+			line = strings.TrimPrefix(line, padding)
+			start = column
+		}
+
+		indentation := padding + strings.Repeat(" ", len(line)-len(strings.TrimLeft(line, " ")))
+
+		// Leave room for the closing quote of the value
+		rows := wrapLine(line, options.Width-start-1, options.Width-len(indentation)-1)
+
+		if i > 0 {
+			rows[0] = padding + rows[0]
+		}
+
+		lines[i] = strings.Join(rows, "\n"+indentation)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine splits line into rows of at most width characters, the first of
+// which is at most firstWidth characters. Rows are broken after a space where
+// possible so that words are kept together.
+func wrapLine(line string, firstWidth, width int) []string {
+	if firstWidth < minWrapWidth || width < minWrapWidth || utf8.RuneCountInString(line) <= firstWidth {
+		return []string{line}
+	}
+
+	var rows []string
+
+	runes := []rune(line)
+	rowWidth := firstWidth
+	for len(runes) > rowWidth {
+		if len(rows) == maxWrappedRows-1 && !options.NoTruncate {
+			marker := fmt.Sprintf("… (%d more chars)", len(runes)-rowWidth)
+			rows = append(rows, string(runes[:rowWidth]), marker)
+			return rows
+		}
+
+		end := rowWidth
+		if i := lastSpace(runes[:rowWidth]); i > 0 {
+			end = i + 1
+		}
+
+		rows = append(rows, string(runes[:end]))
+		runes = runes[end:]
+		rowWidth = width
+	}
+
+	return append(rows, string(runes))
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == ' ' {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// lastLineWidth returns the number of characters on the last line of s.
+func lastLineWidth(s string) int {
+	return utf8.RuneCountInString(s[strings.LastIndex(s, "\n")+1:])
+}
@@ -0,0 +1,102 @@
+package printer
+
+import (
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// parseYAML returns the document represented by value if it is a YAML mapping
+// or sequence. Scalars are rejected since almost any plain string is a valid
+// YAML scalar.
+func parseYAML(value string) (interface{}, bool) {
+	if strings.TrimSpace(value) == "" {
+		return nil, false
+	}
+
+	// MapSlice preserves the original key order when the document is
+	// re-marshalled.
+	var mapping yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(value), &mapping); err == nil && len(mapping) > 0 {
+		return mapping, true
+	}
+
+	var sequence []interface{}
+	if err := yaml.Unmarshal([]byte(value), &sequence); err == nil && len(sequence) > 0 {
+		return sequence, true
+	}
+
+	return nil, false
+}
+
+// isYAMLDocument reports whether value is a YAML mapping or sequence laid out
+// over multiple lines, which is how Kubernetes manifests, Helm values and
+// cloud-init configurations show up in plans.
+func isYAMLDocument(value string) bool {
+	if !strings.Contains(strings.TrimSpace(value), "\n") {
+		return false
+	}
+
+	_, ok := parseYAML(value)
+	return ok
+}
+
+// normaliseYAML re-marshals a YAML document so that formatting differences
+// (indentation, quoting, flow vs block style) do not show up in diffs.
+//
+// Comments are dropped when re-marshalling, except for the leading ones since
+// those can be significant (e.g. the `#cloud-config` header of cloud-init).
+func normaliseYAML(value string) string {
+	document, ok := parseYAML(value)
+	if !ok {
+		return value
+	}
+
+	normalised, err := yaml.Marshal(document)
+	if err != nil {
+		return value
+	}
+
+	var header string
+	for _, line := range strings.SplitAfter(value, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		header += line
+	}
+
+	return header + string(normalised)
+}
+
+// formatYAML normalises YAML documents, whether single line flow style
+// documents (`{a: 1, b: [x, y]}`), which are expanded into block style, or
+// block style documents laid out over multiple lines. Streams of several
+// documents are left as-is since only the first one would be kept.
+func formatYAML(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.Contains(trimmed, "\n") && !(strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		return "", false
+	}
+	if isYAMLStream(trimmed) {
+		return "", false
+	}
+
+	if _, ok := parseYAML(trimmed); !ok {
+		return "", false
+	}
+
+	return strings.TrimSuffix(normaliseYAML(trimmed), "\n"), true
+}
+
+// isYAMLStream reports whether value holds several YAML documents.
+func isYAMLStream(value string) bool {
+	decoder := yaml.NewDecoder(strings.NewReader(value))
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return false
+	}
+
+	return decoder.Decode(&document) != io.EOF
+}
@@ -0,0 +1,126 @@
+package viewer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[?7l\x1b[2J"
+	exitScreen  = "\x1b[?7h\x1b[?25h\x1b[?1049l"
+)
+
+// ErrNoTerminal is returned by Run when there is no terminal to interact with.
+var ErrNoTerminal = errors.New("the interactive viewer requires a terminal")
+
+// escapeSequences maps the escape sequences sent by the keys the viewer uses
+// to their names.
+var escapeSequences = map[string]string{
+	"[A":  "up",
+	"[B":  "down",
+	"OA":  "up",
+	"OB":  "down",
+	"[H":  "home",
+	"[F":  "end",
+	"OH":  "home",
+	"OF":  "end",
+	"[1~": "home",
+	"[4~": "end",
+	"[5~": "pgup",
+	"[6~": "pgdn",
+}
+
+// Run displays the plan in an interactive full-screen viewer until the user
+// quits. The viewer talks to the controlling terminal directly so that the
+// plan itself can be read from stdin.
+func Run(plan *parser.Plan) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return ErrNoTerminal
+	}
+	defer tty.Close() // nolint: errcheck
+
+	fd := int(tty.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return ErrNoTerminal
+	}
+	defer terminal.Restore(fd, state) // nolint: errcheck
+
+	fmt.Fprint(tty, enterScreen)
+	defer fmt.Fprint(tty, exitScreen)
+
+	m := newModel(plan)
+	reader := bufio.NewReader(tty)
+
+	for {
+		width, height, err := terminal.GetSize(fd)
+		if err != nil {
+			return err
+		}
+		m.resize(width, height)
+
+		fmt.Fprint(tty, m.view())
+
+		key, err := readKey(reader)
+		if err != nil {
+			return err
+		}
+
+		if m.update(key) {
+			return nil
+		}
+	}
+}
+
+// readKey reads a key press from the terminal, naming special keys such as
+// "up" or "ctrl-d".
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case 3:
+		return "ctrl-c", nil
+	case 4:
+		return "ctrl-d", nil
+	case 21:
+		return "ctrl-u", nil
+	case '\t':
+		return "tab", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 8, 127:
+		return "backspace", nil
+	case 27:
+		// A lone escape is the escape key, otherwise the rest of the sequence
+		// is sent along with it.
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+
+		sequence := ""
+		for r.Buffered() > 0 && len(sequence) < 8 {
+			b, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			sequence += string(b)
+
+			if name, ok := escapeSequences[sequence]; ok {
+				return name, nil
+			}
+		}
+
+		return "esc", nil
+	}
+
+	return string(c), nil
+}
@@ -0,0 +1,403 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/dmlittle/scenery/pkg/printer"
+	"github.com/fatih/color"
+)
+
+// gutterWidth is the width of the column holding the cursor marker.
+const gutterWidth = 2
+
+var (
+	// ansiRE matches the colors of the output and the OSC 8 hyperlinks of
+	// resource types, whose URLs must not be searched.
+	ansiRE = regexp.MustCompile("\x1b\\[[0-9;]*m|\x1b\\]8;[^\x07\x1b]*(?:\x07|\x1b\\\\)")
+
+	faintSprintf = color.New(color.Faint).SprintfFunc()
+)
+
+var helpLines = []string{
+	"Navigation",
+	"  j, down        next resource",
+	"  k, up          previous resource",
+	"  g, home        first resource",
+	"  G, end         last resource",
+	"  ctrl-d, pgdn   page down",
+	"  ctrl-u, pgup   page up",
+	"",
+	"Jump to the next resource by action",
+	"  +  create      -  destroy     ~  update",
+	"  r  replace     <  read        >  move",
+	"  i  import      .  forget",
+	"",
+	"Display",
+	"  space, enter   expand/collapse the resource attributes",
+	"  E, C           expand/collapse all resources",
+	"  d              toggle between unified and inline diffs",
+	"",
+	"Search",
+	"  /              search resource addresses and values",
+	"  n, N           next/previous match",
+	"",
+	"  ?              toggle this help",
+	"  q, ctrl-c      quit",
+}
+
+// actionKeys maps the keys jumping between resources to the resource change
+// they jump to.
+var actionKeys = map[string]string{
+	"+": "+",
+	"-": "-",
+	"~": "~",
+	"r": "-/+",
+	"<": "<=",
+	">": parser.MoveChange,
+	"i": parser.ImportChange,
+	".": parser.ForgetChange,
+}
+
+type entry struct {
+	resource *parser.Resource
+	expanded bool
+
+	// drift is set for resources changed outside of Terraform, which are
+	// listed in their own section before the changes Terraform plans to make.
+	drift bool
+
+	// lines is the rendered resource, the first line being its header.
+	lines []string
+
+	// text is the rendered resource without colors, used for searching.
+	text string
+}
+
+// model holds the state of the viewer independently of the terminal so that
+// it can be driven by key presses and rendered to a string.
+type model struct {
+	entries []*entry
+	cursor  int
+	offset  int
+
+	width  int
+	height int
+
+	inline bool
+	help   bool
+
+	// prompt holds the search being typed, nil when not searching.
+	prompt  *string
+	search  string
+	message string
+}
+
+func newModel(plan *parser.Plan) *model {
+	m := &model{}
+
+	for _, r := range plan.Drift {
+		m.entries = append(m.entries, &entry{resource: r, drift: true})
+	}
+
+	for _, r := range plan.Resources {
+		m.entries = append(m.entries, &entry{resource: r})
+	}
+
+	return m
+}
+
+// resize sets the size of the screen and renders the resources to fit it.
+func (m *model) resize(width, height int) {
+	if width == m.width && height == m.height && len(m.entries) > 0 && m.entries[0].lines != nil {
+		return
+	}
+
+	m.width = width
+	m.height = height
+	m.renderEntries()
+}
+
+func (m *model) renderEntries() {
+	previous := printer.CurrentOptions()
+	defer printer.SetOptions(previous)
+
+	options := previous
+	options.Compact = false
+	options.Width = m.width - gutterWidth
+	options.InlineDiffs = m.inline
+	printer.SetOptions(options)
+
+	for _, e := range m.entries {
+		var buf bytes.Buffer
+		if e.drift {
+			printer.FprintDriftResource(&buf, e.resource)
+		} else {
+			printer.FprintResource(&buf, e.resource)
+		}
+
+		e.lines = strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		e.text = strings.ToLower(ansiRE.ReplaceAllString(buf.String(), ""))
+	}
+}
+
+// update handles a key press and reports whether the viewer should exit.
+func (m *model) update(key string) bool {
+	m.message = ""
+
+	if m.prompt != nil {
+		m.updatePrompt(key)
+		return false
+	}
+
+	if m.help {
+		m.help = false
+		return key == "q" || key == "ctrl-c"
+	}
+
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "j", "down":
+		m.moveCursor(m.cursor + 1)
+	case "k", "up":
+		m.moveCursor(m.cursor - 1)
+	case "g", "home":
+		m.moveCursor(0)
+	case "G", "end":
+		m.moveCursor(len(m.entries) - 1)
+	case "ctrl-d", "pgdn":
+		m.scroll(m.bodyHeight())
+	case "ctrl-u", "pgup":
+		m.scroll(-m.bodyHeight())
+	case " ", "enter", "tab":
+		if len(m.entries) > 0 {
+			m.entries[m.cursor].expanded = !m.entries[m.cursor].expanded
+		}
+	case "E", "C":
+		for _, e := range m.entries {
+			e.expanded = key == "E"
+		}
+	case "d":
+		m.inline = !m.inline
+		m.renderEntries()
+	case "/":
+		prompt := ""
+		m.prompt = &prompt
+	case "n":
+		m.find(m.search, 1)
+	case "N":
+		m.find(m.search, -1)
+	case "?":
+		m.help = true
+	default:
+		if change, ok := actionKeys[key]; ok {
+			m.jumpToAction(change)
+		}
+	}
+
+	m.keepCursorVisible()
+
+	return false
+}
+
+func (m *model) updatePrompt(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		m.prompt = nil
+	case "enter":
+		m.search = *m.prompt
+		m.prompt = nil
+		m.find(m.search, 1)
+		m.keepCursorVisible()
+	case "backspace":
+		if p := []rune(*m.prompt); len(p) > 0 {
+			*m.prompt = string(p[:len(p)-1])
+		}
+	default:
+		if len([]rune(key)) == 1 {
+			*m.prompt += key
+		}
+	}
+}
+
+func (m *model) moveCursor(i int) {
+	if i < 0 || i >= len(m.entries) {
+		return
+	}
+
+	m.cursor = i
+}
+
+// find moves the cursor to the next (or previous) resource whose address or
+// values contain the query, expanding it if the match is not in its header.
+func (m *model) find(query string, direction int) {
+	if query == "" || len(m.entries) == 0 {
+		return
+	}
+
+	query = strings.ToLower(query)
+	for step := 1; step <= len(m.entries); step++ {
+		i := (m.cursor + direction*step + len(m.entries)) % len(m.entries)
+		e := m.entries[i]
+
+		if strings.Contains(e.text, query) {
+			m.cursor = i
+			if !strings.Contains(strings.ToLower(ansiRE.ReplaceAllString(e.lines[0], "")), query) {
+				e.expanded = true
+			}
+			return
+		}
+	}
+
+	m.message = fmt.Sprintf("No match for %q", query)
+}
+
+// jumpToAction moves the cursor to the next resource with the given change.
+// Changes made outside of Terraform are skipped.
+func (m *model) jumpToAction(change string) {
+	for step := 1; step <= len(m.entries); step++ {
+		i := (m.cursor + step) % len(m.entries)
+		if !m.entries[i].drift && *m.entries[i].resource.Header.Change == change {
+			m.cursor = i
+			return
+		}
+	}
+
+	m.message = fmt.Sprintf("No %s resources", change)
+}
+
+// displayLines returns every line of the resource list along with the index
+// of the line holding the header of each resource. Plans with changes made
+// outside of Terraform are split into two sections under their headings.
+func (m *model) displayLines() ([]string, []int) {
+	var lines []string
+	headers := make([]int, len(m.entries))
+
+	drift := len(m.entries) > 0 && m.entries[0].drift
+	for i, e := range m.entries {
+		switch {
+		case drift && i == 0:
+			lines = append(lines, strings.Repeat(" ", gutterWidth)+printer.DriftHeading())
+		case drift && !e.drift && m.entries[i-1].drift:
+			lines = append(lines, strings.Repeat(" ", gutterWidth)+printer.PlannedHeading())
+		}
+
+		headers[i] = len(lines)
+
+		gutter := strings.Repeat(" ", gutterWidth)
+		if i == m.cursor {
+			gutter = "> "
+		}
+
+		header := gutter + e.lines[0]
+		if !e.expanded && len(e.lines) > 1 {
+			header += " " + faintSprintf("[+%d lines]", len(e.lines)-1)
+		}
+		lines = append(lines, header)
+
+		if e.expanded {
+			for _, l := range e.lines[1:] {
+				lines = append(lines, strings.Repeat(" ", gutterWidth)+l)
+			}
+		}
+	}
+
+	return lines, headers
+}
+
+func (m *model) bodyHeight() int {
+	if m.height < 2 {
+		return 1
+	}
+
+	// The last line is taken by the status bar
+	return m.height - 1
+}
+
+func (m *model) scroll(delta int) {
+	lines, headers := m.displayLines()
+
+	m.offset += delta
+	if max := len(lines) - m.bodyHeight(); m.offset > max {
+		m.offset = max
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+
+	// Move the cursor to the first resource whose header is in view
+	for i, h := range headers {
+		if h >= m.offset {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *model) keepCursorVisible() {
+	if len(m.entries) == 0 {
+		return
+	}
+
+	_, headers := m.displayLines()
+	line := headers[m.cursor]
+
+	if line < m.offset {
+		m.offset = line
+	} else if line >= m.offset+m.bodyHeight() {
+		m.offset = line - m.bodyHeight() + 1
+	}
+}
+
+// view renders the screen.
+func (m *model) view() string {
+	var body []string
+	if m.help {
+		body = helpLines
+	} else {
+		lines, _ := m.displayLines()
+		if m.offset < len(lines) {
+			body = lines[m.offset:]
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+
+	for i := 0; i < m.bodyHeight(); i++ {
+		if i < len(body) {
+			buf.WriteString(body[i])
+		}
+		buf.WriteString("\x1b[0m\x1b[K\r\n")
+	}
+
+	buf.WriteString("\x1b[7m" + m.status() + "\x1b[K\x1b[0m")
+
+	return buf.String()
+}
+
+func (m *model) status() string {
+	switch {
+	case m.prompt != nil:
+		return "/" + *m.prompt
+	case m.message != "":
+		return m.message
+	case len(m.entries) == 0:
+		return "No resources  (q to quit)"
+	}
+
+	address := *m.entries[m.cursor].resource.Header.Name
+	if m.entries[m.cursor].drift {
+		address += " (changed outside of Terraform)"
+	}
+	diffStyle := "unified"
+	if m.inline {
+		diffStyle = "inline"
+	}
+
+	return fmt.Sprintf("%d/%d %s  [%s diffs]  ? for help", m.cursor+1, len(m.entries), address, diffStyle)
+}
@@ -0,0 +1,174 @@
+package viewer
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/dmlittle/scenery/pkg/parser"
+	"github.com/dmlittle/scenery/pkg/printer"
+	"github.com/fatih/color"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestModel(t *testing.T) *model {
+	color.NoColor = true
+
+	input, err := ioutil.ReadFile("../../fixtures/rawPlans/collapseInput.txt")
+	assert.NoError(t, err)
+
+	plan, err := parser.Parse(string(input))
+	assert.NoError(t, err)
+
+	m := newModel(plan)
+	m.resize(80, 10)
+
+	return m
+}
+
+func sendKeys(m *model, keys ...string) bool {
+	quit := false
+	for _, k := range keys {
+		quit = m.update(k)
+	}
+
+	return quit
+}
+
+func TestNavigation(t *testing.T) {
+	cases := []struct {
+		name   string
+		keys   []string
+		cursor int
+	}{
+		{"moves down", []string{"j"}, 1},
+		{"stops at the last resource", []string{"j", "down", "j", "j"}, 2},
+		{"stops at the first resource", []string{"j", "k", "up"}, 0},
+		{"jumps to the last resource", []string{"G"}, 2},
+		{"jumps to the first resource", []string{"G", "g"}, 0},
+		{"jumps to the next update", []string{"~"}, 1},
+		{"jumps to the next create", []string{"+"}, 2},
+		{"wraps around when jumping", []string{"+", "+"}, 0},
+		{"stays put without matching action", []string{"j", "-"}, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestModel(t)
+			sendKeys(m, tc.keys...)
+
+			assert.Equal(t, tc.cursor, m.cursor)
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	m := newTestModel(t)
+
+	lines, _ := m.displayLines()
+	assert.Equal(t, []string{
+		"> + aws_instance.web [+6 lines]",
+		"  ~ aws_security_group.web [+2 lines]",
+		"  + aws_eip.web [+2 lines]",
+	}, lines)
+
+	sendKeys(m, "enter")
+	lines, headers := m.displayLines()
+	assert.Equal(t, []int{0, 7, 8}, headers)
+	assert.Contains(t, lines[2], `ami:`)
+
+	sendKeys(m, "C")
+	_, headers = m.displayLines()
+	assert.Equal(t, []int{0, 1, 2}, headers)
+
+	sendKeys(m, "E")
+	_, headers = m.displayLines()
+	assert.Equal(t, []int{0, 7, 10}, headers)
+}
+
+func TestSearch(t *testing.T) {
+	m := newTestModel(t)
+
+	sendKeys(m, "/", "w", "e", "b", "-", "s", "x", "backspace", "g", "enter")
+	assert.Nil(t, m.prompt)
+	assert.Equal(t, "web-sg", m.search)
+	assert.Equal(t, 1, m.cursor)
+	assert.True(t, m.entries[1].expanded, "matches in values expand the resource")
+
+	sendKeys(m, "/", "E", "I", "P", "enter")
+	assert.Equal(t, 2, m.cursor)
+	assert.False(t, m.entries[2].expanded, "matches in the header do not expand the resource")
+
+	sendKeys(m, "n")
+	assert.Equal(t, 2, m.cursor)
+
+	sendKeys(m, "/", "n", "o", "p", "e", "enter")
+	assert.Equal(t, 2, m.cursor)
+	assert.Equal(t, `No match for "nope"`, m.message)
+}
+
+func TestScrolling(t *testing.T) {
+	m := newTestModel(t)
+	sendKeys(m, "E", "G")
+
+	// 7 + 3 + 3 lines, 9 of which fit above the status bar
+	assert.Equal(t, 2, m.offset)
+
+	sendKeys(m, "pgup")
+	assert.Equal(t, 0, m.offset)
+	assert.Equal(t, 0, m.cursor)
+}
+
+func TestQuit(t *testing.T) {
+	m := newTestModel(t)
+
+	assert.False(t, sendKeys(m, "?"))
+	assert.True(t, m.help)
+	assert.False(t, sendKeys(m, "j"), "any key closes the help")
+	assert.False(t, m.help)
+	assert.True(t, sendKeys(m, "q"))
+}
+
+func TestDriftSection(t *testing.T) {
+	color.NoColor = true
+
+	input, err := ioutil.ReadFile("../../fixtures/rawPlans/driftInput.txt")
+	assert.NoError(t, err)
+
+	plan, err := parser.Parse(string(input))
+	assert.NoError(t, err)
+
+	m := newModel(plan)
+	m.resize(80, 10)
+
+	lines, headers := m.displayLines()
+	assert.Equal(t, []string{
+		"  Objects changed outside of Terraform:",
+		"> ~ aws_instance.web [+6 lines]",
+		"  - aws_s3_bucket.old [+2 lines]",
+		"  Changes planned by Terraform:",
+		"  ~ aws_instance.web [+4 lines]",
+		"  + aws_s3_bucket.old [+6 lines]",
+	}, lines)
+	assert.Equal(t, []int{1, 2, 4, 5}, headers)
+	assert.Contains(t, m.status(), "aws_instance.web (changed outside of Terraform)")
+
+	// Jumping between actions skips the changes made outside of Terraform
+	sendKeys(m, "~")
+	assert.Equal(t, 2, m.cursor)
+}
+
+func TestSearchIgnoresHyperlinks(t *testing.T) {
+	previous := printer.CurrentOptions()
+	defer printer.SetOptions(previous)
+	printer.SetOptions(printer.Options{Hyperlinks: true})
+
+	m := newTestModel(t)
+	assert.Contains(t, m.entries[0].lines[0], "registry.terraform.io")
+
+	sendKeys(m, "/", "r", "e", "g", "i", "s", "t", "r", "y", "enter")
+	assert.Equal(t, `No match for "registry"`, m.message)
+
+	sendKeys(m, "/", "a", "w", "s", "_", "e", "i", "p", "enter")
+	assert.Equal(t, 2, m.cursor)
+}